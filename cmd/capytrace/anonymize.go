@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/andev0x/capytrace.nvim/internal/anonymize"
+	"github.com/andev0x/capytrace.nvim/internal/exporter"
+	"github.com/andev0x/capytrace.nvim/internal/store"
+)
+
+// handleAnonymize re-exports a recorded session with identity stripped, for posting publicly.
+func handleAnonymize() {
+	if len(os.Args) < 4 {
+		fmt.Fprintf(os.Stderr, "Usage: anonymize <session_id> <save_path> [--format markdown+json] [--drop-text] [--drop-args]\n")
+		os.Exit(1)
+	}
+
+	sessionID, savePath := os.Args[2], os.Args[3]
+
+	fs := flag.NewFlagSet("anonymize", flag.ExitOnError)
+	format := fs.String("format", "", "output formats to write (default: the session's own)")
+	dropText := fs.Bool("drop-text", false, "remove the text of edited lines")
+	dropArgs := fs.Bool("drop-args", false, "keep only the program name of terminal commands")
+	_ = fs.Parse(os.Args[4:])
+
+	session, err := store.Read(savePath, sessionID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load session: %v\n", err)
+		os.Exit(1)
+	}
+	if *format != "" {
+		if err := exporter.ValidateFormats(*format); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid format: %v\n", err)
+			os.Exit(1)
+		}
+		session.OutputFormat = *format
+	}
+
+	options := map[string]string{anonymize.OptionAnonymize: "true"}
+	if *dropText {
+		options[anonymize.OptionDropText] = "true"
+	}
+	if *dropArgs {
+		options[anonymize.OptionDropArgs] = "true"
+	}
+	applyExportOptions(session, options)
+
+	results := exporter.Run(session, savePath, store.DataDir())
+	artifacts, err := exporter.Collect(results)
+	for _, result := range results {
+		if result.Err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to export %s: %v\n", result.Format, result.Err)
+		}
+	}
+	for _, artifact := range artifacts {
+		fmt.Printf("  %s: %s\n", artifact.Format, artifact.Path)
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+	fmt.Printf("Mappings kept locally in %s\n", filepath.Join(store.DataDir(), anonymize.DirName))
+}
+
+// handleDeanonymize restores the original names in text from an anonymized export,
+// using the mapping saved on this machine.
+func handleDeanonymize() {
+	if len(os.Args) < 3 {
+		fmt.Fprintf(os.Stderr, "Usage: deanonymize <anonymized_session_id> [file]\n")
+		os.Exit(1)
+	}
+
+	mapping, err := anonymize.LoadMapping(filepath.Join(store.DataDir(), anonymize.DirName), os.Args[2])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load mapping: %v\n", err)
+		os.Exit(1)
+	}
+
+	var data []byte
+	if len(os.Args) > 3 {
+		data, err = os.ReadFile(os.Args[3])
+	} else {
+		data, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read input: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Print(mapping.Reveal(string(data)))
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/andev0x/capytrace.nvim/internal/bundle"
+	"github.com/andev0x/capytrace.nvim/internal/recorder"
+)
+
+// handleBundle packs a session into a single .capytrace.tar.zst file for sharing.
+func handleBundle() {
+	if len(os.Args) < 4 {
+		fmt.Fprintf(os.Stderr, "Usage: bundle <session_id> <save_path> [--out FILE] [--snapshots] [--max-snapshot-size BYTES]\n")
+		os.Exit(1)
+	}
+
+	sessionID, savePath := os.Args[2], os.Args[3]
+
+	fs := flag.NewFlagSet("bundle", flag.ExitOnError)
+	out := fs.String("out", sessionID+bundle.Extension, "bundle file to write")
+	withSnapshots := fs.Bool("snapshots", false, "include the current contents of the files the session touched")
+	maxSize := fs.Int64("max-snapshot-size", bundle.DefaultMaxSnapshotSize, "skip snapshots of files larger than this many bytes")
+	_ = fs.Parse(os.Args[4:])
+
+	if recorder.IsHeld(sessionID, savePath) {
+		fmt.Fprintf(os.Stderr, "Failed to bundle session: session %s is active; end it first\n", sessionID)
+		os.Exit(1)
+	}
+
+	file, err := os.Create(*out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create bundle: %v\n", err)
+		os.Exit(1)
+	}
+	manifest, err := bundle.Create(file, savePath, sessionID, bundle.Options{Snapshots: *withSnapshots, MaxSnapshotSize: *maxSize})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(*out)
+		fmt.Fprintf(os.Stderr, "Failed to bundle session: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Session bundled: %s (%d files)\n", *out, len(manifest.Files))
+}
+
+// handleImport validates a bundle and installs its session into a save path and SQLite.
+func handleImport() {
+	if len(os.Args) < 4 {
+		fmt.Fprintf(os.Stderr, "Usage: import <bundle> <save_path> [--snapshots DIR]\n")
+		os.Exit(1)
+	}
+
+	bundlePath, savePath := os.Args[2], os.Args[3]
+
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	snapshotDir := fs.String("snapshots", "", "extract the bundled file snapshots into this directory")
+	_ = fs.Parse(os.Args[4:])
+
+	b, err := bundle.OpenFile(bundlePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read bundle: %v\n", err)
+		os.Exit(1)
+	}
+
+	id, err := b.Install(savePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to import session: %v\n", err)
+		os.Exit(1)
+	}
+	if id != b.Manifest.SessionID {
+		fmt.Printf("Session imported: %s (renamed from %s)\n", id, b.Manifest.SessionID)
+	} else {
+		fmt.Printf("Session imported: %s\n", id)
+	}
+
+	if *snapshotDir != "" && len(b.Snapshots) > 0 {
+		if err := b.ExtractSnapshots(*snapshotDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to extract snapshots: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Snapshots extracted: %d files in %s\n", len(b.Snapshots), *snapshotDir)
+	}
+}
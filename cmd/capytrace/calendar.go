@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/andev0x/capytrace.nvim/internal/aggregator"
+	"github.com/andev0x/capytrace.nvim/internal/exporter"
+	"github.com/andev0x/capytrace.nvim/internal/store"
+)
+
+// handleCalendar writes the sessions in a date range as one iCalendar file.
+func handleCalendar() {
+	if len(os.Args) < 3 {
+		fmt.Fprintf(os.Stderr, "Usage: calendar <save_path> [--since DATE] [--until DATE] [--project NAME] [--granularity session|block] [--out FILE]\n")
+		os.Exit(1)
+	}
+
+	savePath := os.Args[2]
+
+	fs := flag.NewFlagSet("calendar", flag.ExitOnError)
+	var rf rangeFlags
+	rf.bind(fs)
+	granularity := fs.String("granularity", exporter.GranularitySession, "one event per active period of a session (session) or per run of activity blocks (block)")
+	out := fs.String("out", "", "write the calendar to this file instead of stdout")
+	_ = fs.Parse(os.Args[3:])
+
+	if *granularity != exporter.GranularitySession && *granularity != exporter.GranularityBlock {
+		fmt.Fprintf(os.Stderr, "Invalid --granularity %q: use session or block\n", *granularity)
+		os.Exit(1)
+	}
+
+	q, err := rf.query()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	sessions, err := store.LoadRange(savePath, q)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load sessions: %v\n", err)
+		os.Exit(1)
+	}
+
+	exp := exporter.NewICSExporter(aggregator.DefaultConfig())
+	exp.Granularity = *granularity
+
+	if *out == "" {
+		if err := exp.Write(os.Stdout, sessions); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write calendar: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	file, err := os.Create(*out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create %s: %v\n", *out, err)
+		os.Exit(1)
+	}
+	err = exp.Write(file, sessions)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write calendar: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Calendar written: %s (%d sessions)\n", *out, len(sessions))
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/andev0x/capytrace.nvim/internal/exporter"
+	"github.com/andev0x/capytrace.nvim/internal/store"
+)
+
+// handleCast exports a session as an asciicast v2 recording.
+func handleCast() {
+	if len(os.Args) < 4 {
+		fmt.Fprintf(os.Stderr, "Usage: cast <session_id> <save_path> [--max-idle 2s] [--width 100] [--height 30] [--out FILE]\n")
+		os.Exit(1)
+	}
+
+	sessionID, savePath := os.Args[2], os.Args[3]
+
+	cast := exporter.NewCastExporter()
+	fs := flag.NewFlagSet("cast", flag.ExitOnError)
+	fs.DurationVar(&cast.MaxIdle, "max-idle", cast.MaxIdle, "shorten pauses between frames to at most this long (0 keeps real timing)")
+	fs.IntVar(&cast.Width, "width", cast.Width, "terminal width in columns")
+	fs.IntVar(&cast.Height, "height", cast.Height, "terminal height in rows")
+	out := fs.String("out", "", "write the cast to this file instead of <save_path>/<session_id>.cast")
+	_ = fs.Parse(os.Args[4:])
+
+	session, err := store.Read(savePath, sessionID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load session: %v\n", err)
+		os.Exit(1)
+	}
+
+	path := *out
+	if path == "" {
+		path = filepath.Join(savePath, sessionID+".cast")
+	}
+
+	if err := cast.WriteFile(path, session); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to export cast: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Cast written: %s (play with: asciinema play %s)\n", path, path)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/andev0x/capytrace.nvim/internal/aggregator"
+	"github.com/andev0x/capytrace.nvim/internal/exporter"
+	"github.com/andev0x/capytrace.nvim/internal/store"
+)
+
+// handleCSV exports the events, activity blocks and file focus of many sessions into
+// one set of spreadsheet tables.
+func handleCSV() {
+	if len(os.Args) < 3 {
+		fmt.Fprintf(os.Stderr, "Usage: csv <save_path> [--since DATE] [--until DATE] [--project NAME] [--tsv] [--out DIR] [--prefix sessions]\n")
+		os.Exit(1)
+	}
+
+	savePath := os.Args[2]
+
+	fs := flag.NewFlagSet("csv", flag.ExitOnError)
+	var rf rangeFlags
+	rf.bind(fs)
+	tsv := fs.Bool("tsv", false, "write tab-separated .tsv files")
+	out := fs.String("out", ".", "directory to write the tables to")
+	prefix := fs.String("prefix", "sessions", "file name prefix: {prefix}_events.csv, {prefix}_blocks.csv, {prefix}_files.csv")
+	_ = fs.Parse(os.Args[3:])
+
+	q, err := rf.query()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	sessions, err := store.LoadRange(savePath, q)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load sessions: %v\n", err)
+		os.Exit(1)
+	}
+
+	exp := exporter.NewCSVExporter(aggregator.DefaultConfig())
+	if *tsv {
+		exp = exporter.NewTSVExporter(aggregator.DefaultConfig())
+	}
+
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create %s: %v\n", *out, err)
+		os.Exit(1)
+	}
+	paths, err := exp.WriteTables(sessions, *out, *prefix)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to export: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Exported %d sessions:\n", len(sessions))
+	for _, path := range paths {
+		fmt.Printf("  %s\n", path)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/dashboard"
+	"github.com/andev0x/capytrace.nvim/internal/store"
+)
+
+// handleDashboard serves the read-only web dashboard and JSON API until interrupted.
+func handleDashboard() {
+	if len(os.Args) < 3 {
+		fmt.Fprintf(os.Stderr, "Usage: dashboard <save_path> [--listen 127.0.0.1:7878]\n")
+		os.Exit(1)
+	}
+
+	savePath := os.Args[2]
+
+	fs := flag.NewFlagSet("dashboard", flag.ExitOnError)
+	listen := fs.String("listen", "127.0.0.1:7878", "address to serve the dashboard on")
+	_ = fs.Parse(os.Args[3:])
+
+	if _, err := os.Stat(savePath); err != nil {
+		fmt.Fprintf(os.Stderr, "Save path not found: %v\n", err)
+		os.Exit(1)
+	}
+
+	server := &http.Server{
+		Addr:              *listen,
+		Handler:           dashboard.New(savePath, store.DataDir()).Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	fmt.Printf("CapyTrace dashboard: http://%s/\n", *listen)
+	if err := server.ListenAndServe(); err != nil {
+		fmt.Fprintf(os.Stderr, "Dashboard server failed: %v\n", err)
+		os.Exit(1)
+	}
+}
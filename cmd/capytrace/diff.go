@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/andev0x/capytrace.nvim/internal/aggregator"
+	"github.com/andev0x/capytrace.nvim/internal/exporter"
+	"github.com/andev0x/capytrace.nvim/internal/report"
+	"github.com/andev0x/capytrace.nvim/internal/store"
+)
+
+// handleDiff compares two sessions: files, time per file, commands, diagnostics, flow
+// time and a timeline aligned by time since each session started.
+func handleDiff() {
+	if len(os.Args) < 5 {
+		fmt.Fprintf(os.Stderr, "Usage: diff <session_a> <session_b> <save_path> [--format markdown|json] [--bucket 5m] [--template DIR] [--out FILE]\n")
+		os.Exit(1)
+	}
+
+	idA, idB, savePath := os.Args[2], os.Args[3], os.Args[4]
+
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := fs.String("format", "markdown", "output format: markdown or json")
+	bucket := fs.Duration("bucket", report.DefaultDiffBucket, "width of the aligned timeline rows")
+	templateDir := fs.String("template", "", "directory with a diff.md overriding the built-in template")
+	out := fs.String("out", "", "write the diff to this file instead of stdout")
+	_ = fs.Parse(os.Args[5:])
+
+	a, err := store.Read(savePath, idA)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load session %s: %v\n", idA, err)
+		os.Exit(1)
+	}
+	b, err := store.Read(savePath, idB)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load session %s: %v\n", idB, err)
+		os.Exit(1)
+	}
+
+	// Without --template, the directory chosen when the first session was started is used
+	dir := a.ExportOptions[exporter.OptionTemplateDir]
+	if *templateDir != "" {
+		if dir, err = filepath.Abs(*templateDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to resolve template directory: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	d := report.BuildDiff(a, b, *bucket, aggregator.DefaultConfig())
+	content, err := exporter.RenderDiff(d, *format, dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to render diff: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *out == "" {
+		fmt.Print(string(content))
+		return
+	}
+	if err := os.WriteFile(*out, content, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write diff: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Diff written: %s\n", *out)
+}
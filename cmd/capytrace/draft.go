@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andev0x/capytrace.nvim/internal/aggregator"
+	"github.com/andev0x/capytrace.nvim/internal/draft"
+	"github.com/andev0x/capytrace.nvim/internal/exporter"
+	"github.com/andev0x/capytrace.nvim/internal/store"
+)
+
+// handleDraft writes a pull-request, commit message or postmortem draft for a session.
+func handleDraft() {
+	if len(os.Args) < 4 {
+		fmt.Fprintf(os.Stderr, "Usage: draft <session_id> <save_path> [--kind %s] [--template DIR] [--out FILE]\n", strings.Join(draft.Kinds(), "|"))
+		os.Exit(1)
+	}
+
+	sessionID, savePath := os.Args[2], os.Args[3]
+
+	fs := flag.NewFlagSet("draft", flag.ExitOnError)
+	kind := fs.String("kind", draft.KindPR, "kind of draft: "+strings.Join(draft.Kinds(), ", "))
+	templateDir := fs.String("template", "", "directory with draft_pr.md / draft_commit.md / postmortem.md overriding the built-in templates")
+	out := fs.String("out", "", "write the draft to this file instead of stdout")
+	_ = fs.Parse(os.Args[4:])
+
+	content, err := renderDraft(sessionID, savePath, *kind, *templateDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to draft: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *out == "" {
+		fmt.Print(content)
+		return
+	}
+	if err := os.WriteFile(*out, []byte(content), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write %s: %v\n", *out, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Draft written: %s\n", *out)
+}
+
+// renderDraft loads a session and renders its draft. Without an explicit template
+// directory the one chosen when the session was started is used. A postmortem draft is
+// the same document as the postmortem export.
+func renderDraft(sessionID, savePath, kind, templateDir string) (string, error) {
+	session, err := store.Read(savePath, sessionID)
+	if err != nil {
+		return "", err
+	}
+
+	dir := session.ExportOptions[exporter.OptionTemplateDir]
+	if templateDir != "" {
+		if dir, err = filepath.Abs(templateDir); err != nil {
+			return "", err
+		}
+	}
+
+	if kind == draft.KindPostmortem {
+		e := exporter.NewPostmortemExporter(aggregator.DefaultConfig())
+		e.TemplateDir = dir
+		return e.Render(session)
+	}
+
+	d, err := draft.Build(session, aggregator.New(aggregator.DefaultConfig()), kind)
+	if err != nil {
+		return "", err
+	}
+	return exporter.RenderDraft(d, dir)
+}
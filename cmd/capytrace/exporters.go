@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/andev0x/capytrace.nvim/internal/exporter"
+)
+
+// handleListExporters prints the built-in exporters and the plugins found on PATH.
+func handleListExporters() {
+	fs := flag.NewFlagSet("list-exporters", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the exporters as JSON")
+	_ = fs.Parse(os.Args[2:])
+
+	available := exporter.List()
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(available); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to encode exporters: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tEXTENSION\tSOURCE\tDESCRIPTION")
+	for _, exp := range available {
+		description := exp.Description
+		if exp.Error != "" {
+			description = "unavailable: " + exp.Error
+		}
+		extension := exp.Extension
+		if extension == "" {
+			extension = "-" // Writes into shared files rather than {session_id}{extension}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", exp.Name, extension, exp.Source, description)
+		for _, option := range exp.Options {
+			line := "  --option " + option.Name + "=..."
+			if option.Default != "" {
+				line += " (default " + option.Default + ")"
+			}
+			fmt.Fprintf(w, "\t\t\t%s\n", strings.TrimSpace(line+"  "+option.Description))
+		}
+	}
+	_ = w.Flush()
+}
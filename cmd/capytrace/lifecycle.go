@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/andev0x/capytrace.nvim/internal/recorder"
+	"github.com/andev0x/capytrace.nvim/internal/store"
+)
+
+// lifecycleUsage lists the arguments of each session management command.
+var lifecycleUsage = map[string]string{
+	"rename":    "rename <session_id> <save_path> <new_id>",
+	"copy":      "copy <session_id> <save_path> <new_id>",
+	"delete":    "delete <session_id> <save_path>",
+	"archive":   "archive <session_id> <save_path>",
+	"unarchive": "unarchive <session_id> <save_path>",
+}
+
+// handleLifecycle runs a session management command from the command line.
+func handleLifecycle(action string) {
+	message, err := runLifecycle(action, os.Args[2:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to %s session: %v\n", action, err)
+		os.Exit(1)
+	}
+	fmt.Println(message)
+}
+
+// runLifecycle renames, copies, deletes, archives or unarchives a stored session.
+// Sessions that are still being recorded by a running process, or that were saved
+// as active and never ended, are refused.
+func runLifecycle(action string, args []string) (string, error) {
+	needed := 2
+	if action == "rename" || action == "copy" {
+		needed = 3
+	}
+	if len(args) < needed {
+		return "", fmt.Errorf("usage: %s", lifecycleUsage[action])
+	}
+
+	sessionID, savePath := args[0], args[1]
+	if action != "unarchive" {
+		if err := ensureInactive(sessionID, savePath); err != nil {
+			return "", err
+		}
+	}
+
+	switch action {
+	case "rename":
+		if err := store.Rename(savePath, sessionID, args[2]); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Session renamed: %s -> %s", sessionID, args[2]), nil
+	case "copy":
+		if err := store.Copy(savePath, sessionID, args[2]); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Session copied: %s -> %s", sessionID, args[2]), nil
+	case "delete":
+		info, err := store.Stat(savePath, sessionID)
+		if err != nil {
+			return "", err
+		}
+		if err := store.Delete(info); err != nil {
+			return "", err
+		}
+		return "Session deleted: " + sessionID, nil
+	case "archive":
+		if err := store.Archive(savePath, sessionID); err != nil {
+			return "", err
+		}
+		return "Session archived: " + sessionID, nil
+	case "unarchive":
+		if err := store.Unarchive(savePath, sessionID); err != nil {
+			return "", err
+		}
+		return "Session unarchived: " + sessionID, nil
+	default:
+		return "", fmt.Errorf("unknown command: %s", action)
+	}
+}
+
+// ensureInactive refuses sessions that are still being recorded by a running process,
+// or that were saved as active and never ended.
+func ensureInactive(sessionID, savePath string) error {
+	if recorder.IsHeld(sessionID, savePath) {
+		return fmt.Errorf("session %s is active; end it first", sessionID)
+	}
+	if session, err := store.Read(savePath, sessionID); err == nil && session.Active {
+		return fmt.Errorf("session %s is active; end it first", sessionID)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/andev0x/capytrace.nvim/internal/locations"
+	"github.com/andev0x/capytrace.nvim/internal/store"
+)
+
+// handleLocations prints the places a session touched for Vim's quickfix list.
+func handleLocations() {
+	if len(os.Args) < 4 {
+		fmt.Fprintf(os.Stderr, "Usage: locations <session_id> <save_path> [--kind %s] [--rank count|recent] [--limit N] [--json]\n", strings.Join(locations.Kinds(), "|"))
+		os.Exit(1)
+	}
+
+	sessionID, savePath := os.Args[2], os.Args[3]
+
+	fs := flag.NewFlagSet("locations", flag.ExitOnError)
+	kind := fs.String("kind", locations.KindEdits, "what to list: "+strings.Join(locations.Kinds(), ", "))
+	rank := fs.String("rank", locations.RankCount, "order by event count (count) or latest event (recent)")
+	limit := fs.Int("limit", 0, "print at most this many locations (0 for all)")
+	asJSON := fs.Bool("json", false, "print a JSON array of setqflist() items instead of errorformat lines")
+	_ = fs.Parse(os.Args[4:])
+
+	list, err := listLocations(sessionID, savePath, *kind, *rank, *limit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to list locations: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *asJSON {
+		encoded, err := encodeLocations(list)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to encode locations: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(encoded)
+		return
+	}
+	if err := locations.WriteErrorformat(os.Stdout, list); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write locations: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// listLocations loads a session and returns at most limit of its locations (0 for all).
+func listLocations(sessionID, savePath, kind, rank string, limit int) ([]locations.Location, error) {
+	session, err := store.Read(savePath, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	list, err := locations.List(session, kind, rank)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+// encodeLocations returns locations as a JSON array, never null.
+func encodeLocations(list []locations.Location) (string, error) {
+	if list == nil {
+		list = []locations.Location{}
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
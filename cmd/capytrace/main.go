@@ -5,14 +5,18 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/andev0x/capytrace.nvim/internal/exporter"
 	"github.com/andev0x/capytrace.nvim/internal/filter"
+	"github.com/andev0x/capytrace.nvim/internal/locations"
 	"github.com/andev0x/capytrace.nvim/internal/models"
 	"github.com/andev0x/capytrace.nvim/internal/recorder"
+	"github.com/andev0x/capytrace.nvim/internal/store"
 )
 
 type daemonRequest struct {
@@ -22,16 +26,18 @@ type daemonRequest struct {
 }
 
 type daemonResponse struct {
-	ID         int    `json:"id"`
-	OK         bool   `json:"ok"`
-	Result     string `json:"result,omitempty"`
-	Error      string `json:"error,omitempty"`
-	ReportPath string `json:"report_path,omitempty"`
+	ID         int                 `json:"id"`
+	OK         bool                `json:"ok"`
+	Result     string              `json:"result,omitempty"`
+	Error      string              `json:"error,omitempty"`
+	ReportPath string              `json:"report_path,omitempty"`
+	Artifacts  []exporter.Artifact `json:"artifacts,omitempty"`
 }
 
 type commandResult struct {
 	Message    string
 	ReportPath string
+	Artifacts  []exporter.Artifact
 }
 
 func main() {
@@ -49,7 +55,32 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  list               List all sessions\n")
 		fmt.Fprintf(os.Stderr, "  resume             Resume a previous session\n")
 		fmt.Fprintf(os.Stderr, "  stats              Show session statistics\n")
-		fmt.Fprintf(os.Stderr, "  daemon             Start long-lived daemon mode\n")
+		fmt.Fprintf(os.Stderr, "  report             Roll up sessions by day, week, project or file\n")
+		fmt.Fprintf(os.Stderr, "  diff               Compare two sessions side by side, aligned by elapsed time\n")
+		fmt.Fprintf(os.Stderr, "  dashboard          Serve the web dashboard and JSON API\n")
+		fmt.Fprintf(os.Stderr, "  tail               Follow a running session in the terminal\n")
+		fmt.Fprintf(os.Stderr, "  replay             Play back a recorded session in the terminal\n")
+		fmt.Fprintf(os.Stderr, "  cast               Export a session as an asciicast recording\n")
+		fmt.Fprintf(os.Stderr, "  csv                Export many sessions as CSV/TSV tables\n")
+		fmt.Fprintf(os.Stderr, "  calendar           Export sessions as iCalendar events for time tracking\n")
+		fmt.Fprintf(os.Stderr, "  wakatime           Export WakaTime heartbeats or import a heartbeat dump\n")
+		fmt.Fprintf(os.Stderr, "  draft              Draft a PR description, commit message or postmortem from a session\n")
+		fmt.Fprintf(os.Stderr, "  locations          List edited lines, diagnostics, notes or hotspots for the quickfix list\n")
+		fmt.Fprintf(os.Stderr, "  template           Write the default report templates for customization\n")
+		fmt.Fprintf(os.Stderr, "  list-exporters     Show built-in exporters and capytrace-export-* plugins\n")
+		fmt.Fprintf(os.Stderr, "  rename             Rename a session\n")
+		fmt.Fprintf(os.Stderr, "  copy               Duplicate a session under a new ID\n")
+		fmt.Fprintf(os.Stderr, "  delete             Delete a session and its exports\n")
+		fmt.Fprintf(os.Stderr, "  archive            Move a session into the archive\n")
+		fmt.Fprintf(os.Stderr, "  unarchive          Restore an archived session\n")
+		fmt.Fprintf(os.Stderr, "  bundle             Pack a session into a .capytrace.tar.zst file for sharing\n")
+		fmt.Fprintf(os.Stderr, "  anonymize          Re-export a session with paths, names, hosts and times pseudonymized\n")
+		fmt.Fprintf(os.Stderr, "  deanonymize        Restore original names in text from an anonymized export\n")
+		fmt.Fprintf(os.Stderr, "  import             Validate and install a session bundle\n")
+		fmt.Fprintf(os.Stderr, "  merge              Merge sessions into one timeline\n")
+		fmt.Fprintf(os.Stderr, "  split              Split a session in two\n")
+		fmt.Fprintf(os.Stderr, "  prune              Remove or archive old sessions\n")
+		fmt.Fprintf(os.Stderr, "  daemon             Start long-lived daemon mode (--listen ADDR streams live events)\n")
 		os.Exit(1)
 	}
 
@@ -78,6 +109,48 @@ func main() {
 		handleRecordLSPDiagnostic()
 	case "stats":
 		handleStats()
+	case "report":
+		handleReport()
+	case "diff":
+		handleDiff()
+	case "dashboard":
+		handleDashboard()
+	case "tail":
+		handleTail()
+	case "replay":
+		handleReplay()
+	case "cast":
+		handleCast()
+	case "csv":
+		handleCSV()
+	case "calendar":
+		handleCalendar()
+	case "wakatime":
+		handleWakaTime()
+	case "draft":
+		handleDraft()
+	case "locations":
+		handleLocations()
+	case "template":
+		handleTemplate()
+	case "list-exporters":
+		handleListExporters()
+	case "rename", "copy", "delete", "archive", "unarchive":
+		handleLifecycle(command)
+	case "bundle":
+		handleBundle()
+	case "anonymize":
+		handleAnonymize()
+	case "deanonymize":
+		handleDeanonymize()
+	case "import":
+		handleImport()
+	case "merge":
+		handleMerge()
+	case "split":
+		handleSplit()
+	case "prune":
+		handlePrune()
 	case "daemon":
 		runDaemon()
 	default:
@@ -87,6 +160,20 @@ func main() {
 }
 
 func runDaemon() {
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	listen := fs.String("listen", "", "serve the live event stream (SSE on /events, WebSocket on /ws) on this address")
+	_ = fs.Parse(os.Args[2:])
+
+	// The daemon is the process that records, so it owns the session lock files
+	recorder.HoldLocks()
+
+	if *listen != "" {
+		if err := serveStream(*listen); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to start event stream: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	scanner := bufio.NewScanner(os.Stdin)
 	encoder := json.NewEncoder(os.Stdout)
 
@@ -104,11 +191,18 @@ func runDaemon() {
 
 		result, err := executeDaemonCommand(req.Command, req.Args)
 		if err != nil {
-			_ = encoder.Encode(daemonResponse{ID: req.ID, OK: false, Error: err.Error()})
+			// Artifacts still lists what was produced when only some exporters failed
+			_ = encoder.Encode(daemonResponse{ID: req.ID, OK: false, Error: err.Error(), Artifacts: result.Artifacts})
 			continue
 		}
 
-		_ = encoder.Encode(daemonResponse{ID: req.ID, OK: true, Result: result.Message, ReportPath: result.ReportPath})
+		_ = encoder.Encode(daemonResponse{
+			ID:         req.ID,
+			OK:         true,
+			Result:     result.Message,
+			ReportPath: result.ReportPath,
+			Artifacts:  result.Artifacts,
+		})
 	}
 }
 
@@ -119,7 +213,15 @@ func executeDaemonCommand(command string, args []string) (commandResult, error)
 			return commandResult{}, fmt.Errorf("start requires 4 args")
 		}
 		sessionID, projectPath, savePath, outputFormat := args[0], args[1], args[2], args[3]
+		options, err := parseExportOptions("start", args[4:])
+		if err != nil {
+			return commandResult{}, err
+		}
+		if err := exporter.ValidateFormats(outputFormat); err != nil {
+			return commandResult{}, err
+		}
 		session := recorder.NewSession(sessionID, projectPath, savePath, outputFormat, filter.DefaultFilterConfig())
+		applyExportOptions(session.Session, options)
 		if err := session.Start(); err != nil {
 			return commandResult{}, err
 		}
@@ -129,35 +231,26 @@ func executeDaemonCommand(command string, args []string) (commandResult, error)
 			return commandResult{}, fmt.Errorf("end requires 2 args")
 		}
 		sessionID, savePath := args[0], args[1]
+		options, err := parseExportOptions("end", args[2:])
+		if err != nil {
+			return commandResult{}, err
+		}
 		session, err := recorder.LoadSession(sessionID, savePath, filter.DefaultFilterConfig())
 		if err != nil {
 			return commandResult{}, err
 		}
+		applyExportOptions(session.Session, options)
 		if err := session.End(); err != nil {
 			return commandResult{}, err
 		}
 
-		var exp exporter.Exporter
-		switch session.OutputFormat {
-		case "json":
-			exp = &exporter.JSONExporter{}
-		case "sqlite":
-			home, _ := os.UserHomeDir()
-			dataDir := filepath.Join(home, ".local", "share", "capytrace")
-			if err := os.MkdirAll(dataDir, 0755); err != nil {
-				return commandResult{}, err
-			}
-			exp = exporter.NewSQLiteExporter(dataDir)
-		default:
-			exp = &exporter.MarkdownExporter{}
-		}
-
-		if err := exp.Export(session.Session, session.SavePath); err != nil {
-			return commandResult{}, err
+		artifacts, err := exporter.Collect(exporter.Run(session.Session, session.SavePath, store.DataDir()))
+		result := commandResult{
+			Message:    "Session ended and exported: " + sessionID,
+			ReportPath: reportPath(artifacts),
+			Artifacts:  artifacts,
 		}
-
-		reportPath := filepath.Join(savePath, sessionID+".md")
-		return commandResult{Message: "Session ended and exported: " + sessionID, ReportPath: reportPath}, nil
+		return result, err
 	case "annotate":
 		if len(args) < 3 {
 			return commandResult{}, fmt.Errorf("annotate requires 3 args")
@@ -190,7 +283,12 @@ func executeDaemonCommand(command string, args []string) (commandResult, error)
 		if err != nil {
 			return commandResult{}, err
 		}
-		if err := session.RecordTerminalCommand(args[2]); err != nil {
+		if len(args) >= 4 {
+			err = session.RecordTerminalExit(args[2], args[3])
+		} else {
+			err = session.RecordTerminalCommand(args[2])
+		}
+		if err != nil {
 			return commandResult{}, err
 		}
 		return commandResult{}, nil
@@ -248,6 +346,65 @@ func executeDaemonCommand(command string, args []string) (commandResult, error)
 			return commandResult{}, err
 		}
 		return commandResult{Message: fmt.Sprintf("%v", sessions)}, nil
+	case "rename", "copy", "delete", "archive", "unarchive":
+		message, err := runLifecycle(command, args)
+		if err != nil {
+			return commandResult{}, err
+		}
+		return commandResult{Message: message}, nil
+	case "draft":
+		if len(args) < 3 {
+			return commandResult{}, fmt.Errorf("draft requires 3 args")
+		}
+		content, err := renderDraft(args[0], args[1], args[2], "")
+		if err != nil {
+			return commandResult{}, err
+		}
+		return commandResult{Message: content}, nil
+	case "locations":
+		if len(args) < 3 {
+			return commandResult{}, fmt.Errorf("locations requires 3 args")
+		}
+		rank := locations.RankCount
+		if len(args) >= 4 {
+			rank = args[3]
+		}
+		list, err := listLocations(args[0], args[1], args[2], rank, 0)
+		if err != nil {
+			return commandResult{}, err
+		}
+		encoded, err := encodeLocations(list)
+		if err != nil {
+			return commandResult{}, err
+		}
+		return commandResult{Message: encoded}, nil
+	case "split":
+		message, err := runSplit(args)
+		if err != nil {
+			return commandResult{}, err
+		}
+		return commandResult{Message: message}, nil
+	case "split-suggest":
+		if len(args) < 2 {
+			return commandResult{}, fmt.Errorf("split-suggest requires 2 args")
+		}
+		minGap := 30 * time.Minute
+		if len(args) >= 3 {
+			parsed, err := time.ParseDuration(args[2])
+			if err != nil {
+				return commandResult{}, err
+			}
+			minGap = parsed
+		}
+		suggestions, err := suggestSplits(args[0], args[1], minGap)
+		if err != nil {
+			return commandResult{}, err
+		}
+		encoded, err := encodeSuggestions(suggestions)
+		if err != nil {
+			return commandResult{}, err
+		}
+		return commandResult{Message: encoded}, nil
 	default:
 		return commandResult{}, fmt.Errorf("unknown command: %s", command)
 	}
@@ -256,7 +413,7 @@ func executeDaemonCommand(command string, args []string) (commandResult, error)
 // handleStart initializes a new debugging session.
 func handleStart() {
 	if len(os.Args) < 6 {
-		fmt.Fprintf(os.Stderr, "Usage: start <session_id> <project_path> <save_path> <output_format>\n")
+		fmt.Fprintf(os.Stderr, "Usage: start <session_id> <project_path> <save_path> <output_format[+format...]> [--template DIR] [--anonymize] [--option key=value]\n")
 		os.Exit(1)
 	}
 
@@ -265,8 +422,19 @@ func handleStart() {
 	savePath := os.Args[4]
 	outputFormat := os.Args[5]
 
+	options, err := parseExportOptions("start", os.Args[6:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid options: %v\n", err)
+		os.Exit(1)
+	}
+	if err := exporter.ValidateFormats(outputFormat); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid output format: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Use default filter configuration
 	session := recorder.NewSession(sessionID, projectPath, savePath, outputFormat, filter.DefaultFilterConfig())
+	applyExportOptions(session.Session, options)
 	if err := session.Start(); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to start session: %v\n", err)
 		os.Exit(1)
@@ -278,47 +446,61 @@ func handleStart() {
 // handleEnd terminates the current session and exports it.
 func handleEnd() {
 	if len(os.Args) < 4 {
-		fmt.Fprintf(os.Stderr, "Usage: end <session_id> <save_path>\n")
+		fmt.Fprintf(os.Stderr, "Usage: end <session_id> <save_path> [--template DIR] [--anonymize] [--option key=value]\n")
 		os.Exit(1)
 	}
 
 	sessionID := os.Args[2]
 	savePath := os.Args[3]
 
+	options, err := parseExportOptions("end", os.Args[4:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid options: %v\n", err)
+		os.Exit(1)
+	}
+
 	session, err := recorder.LoadSession(sessionID, savePath, filter.DefaultFilterConfig())
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to load session: %v\n", err)
 		os.Exit(1)
 	}
+	applyExportOptions(session.Session, options)
 
 	if err := session.End(); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to end session: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Export session based on format
-	var exp exporter.Exporter
-	switch session.OutputFormat {
-	case "json":
-		exp = &exporter.JSONExporter{}
-	case "sqlite":
-		home, _ := os.UserHomeDir()
-		dataDir := filepath.Join(home, ".local", "share", "capytrace")
-		if err := os.MkdirAll(dataDir, 0755); err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to create data directory: %v\n", err)
-			os.Exit(1)
+	// Export session with every format in its output format list
+	results := exporter.Run(session.Session, session.SavePath, store.DataDir())
+	artifacts, err := exporter.Collect(results)
+	for _, result := range results {
+		if result.Err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to export %s: %v\n", result.Format, result.Err)
 		}
-		exp = exporter.NewSQLiteExporter(dataDir)
-	default:
-		exp = &exporter.MarkdownExporter{}
 	}
-
-	if err := exp.Export(session.Session, session.SavePath); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to export session: %v\n", err)
+	if err == nil {
+		fmt.Printf("Session ended and exported: %s\n", sessionID)
+	}
+	for _, artifact := range artifacts {
+		fmt.Printf("  %s: %s\n", artifact.Format, artifact.Path)
+	}
+	if err != nil {
 		os.Exit(1)
 	}
+}
 
-	fmt.Printf("Session ended and exported: %s\n", sessionID)
+// reportPath picks the artifact an editor should open after a session ends:
+// the Markdown report, else the HTML page, else none.
+func reportPath(artifacts []exporter.Artifact) string {
+	for _, format := range []string{"markdown", "html"} {
+		for _, artifact := range artifacts {
+			if artifact.Format == format {
+				return artifact.Path
+			}
+		}
+	}
+	return ""
 }
 
 // handleAnnotate adds a user note to the current session.
@@ -377,7 +559,7 @@ func handleRecordEdit() {
 // handleRecordTerminal records a terminal command execution.
 func handleRecordTerminal() {
 	if len(os.Args) < 5 {
-		fmt.Fprintf(os.Stderr, "Usage: record-terminal <session_id> <save_path> <command>\n")
+		fmt.Fprintf(os.Stderr, "Usage: record-terminal <session_id> <save_path> <command> [exit_code]\n")
 		os.Exit(1)
 	}
 
@@ -391,7 +573,12 @@ func handleRecordTerminal() {
 		os.Exit(1)
 	}
 
-	if err := session.RecordTerminalCommand(command); err != nil {
+	if len(os.Args) >= 6 {
+		err = session.RecordTerminalExit(command, os.Args[5])
+	} else {
+		err = session.RecordTerminalCommand(command)
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to record terminal command: %v\n", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/store"
+)
+
+// handlePrune removes or archives old sessions according to a retention policy.
+func handlePrune() {
+	if len(os.Args) < 3 {
+		fmt.Fprintf(os.Stderr, "Usage: prune <save_path> [--older-than 30d] [--keep-last N] [--max-size 500MB] [--keep-tagged] [--keep-annotated] [--archive file.tar.gz] [--dry-run]\n")
+		os.Exit(1)
+	}
+
+	savePath := os.Args[2]
+
+	fs := flag.NewFlagSet("prune", flag.ExitOnError)
+	olderThan := fs.String("older-than", "", "prune sessions older than this age (e.g. 30d, 12h)")
+	keepLast := fs.Int("keep-last", 0, "always keep the N most recent sessions per project")
+	maxSize := fs.String("max-size", "", "prune oldest sessions until the save path fits (e.g. 500MB)")
+	keepTagged := fs.Bool("keep-tagged", false, "never prune sessions with #tags in annotations")
+	keepAnnotated := fs.Bool("keep-annotated", false, "never prune sessions with annotations")
+	archivePath := fs.String("archive", "", "move pruned sessions into this .tar.gz instead of deleting")
+	dryRun := fs.Bool("dry-run", false, "only list the sessions that would be pruned")
+	_ = fs.Parse(os.Args[3:])
+
+	policy := store.PrunePolicy{
+		KeepLast:      *keepLast,
+		KeepTagged:    *keepTagged,
+		KeepAnnotated: *keepAnnotated,
+	}
+
+	if *olderThan != "" {
+		age, err := parseAge(*olderThan)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --older-than: %v\n", err)
+			os.Exit(1)
+		}
+		policy.OlderThan = age
+	}
+	if *maxSize != "" {
+		size, err := parseSize(*maxSize)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --max-size: %v\n", err)
+			os.Exit(1)
+		}
+		policy.MaxTotalSize = size
+	}
+
+	if !policy.Enabled() {
+		fmt.Fprintf(os.Stderr, "Nothing to do: set at least one of --older-than, --keep-last or --max-size\n")
+		os.Exit(1)
+	}
+
+	infos, err := store.Scan(savePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to scan sessions: %v\n", err)
+		os.Exit(1)
+	}
+
+	pruned := store.SelectPrunable(infos, policy, time.Now())
+	if len(pruned) == 0 {
+		fmt.Println("No sessions to prune")
+		return
+	}
+
+	var freed int64
+	for _, info := range pruned {
+		freed += info.Size
+		fmt.Printf("%s  %s  %s  %d files\n",
+			info.StartTime.Format("2006-01-02 15:04"), info.ID, formatSize(info.Size), len(info.Files))
+	}
+
+	if *dryRun {
+		fmt.Printf("\nWould prune %d sessions (%s)\n", len(pruned), formatSize(freed))
+		return
+	}
+
+	if *archivePath != "" {
+		if err := store.WriteArchive(*archivePath, pruned); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write archive: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	for _, info := range pruned {
+		if err := store.Delete(info); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to prune %s: %v\n", info.ID, err)
+			os.Exit(1)
+		}
+	}
+
+	if *archivePath != "" {
+		fmt.Printf("\nArchived %d sessions (%s) to %s\n", len(pruned), formatSize(freed), *archivePath)
+	} else {
+		fmt.Printf("\nPruned %d sessions (%s)\n", len(pruned), formatSize(freed))
+	}
+}
+
+// parseAge parses a Go duration, additionally accepting day (d) and week (w) suffixes.
+func parseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if strings.HasSuffix(s, suffix) {
+			n, err := strconv.Atoi(strings.TrimSuffix(s, suffix))
+			if err != nil {
+				return 0, err
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	return time.ParseDuration(s)
+}
+
+// parseSize parses a byte size such as 2048, 500KB, 500MB or 2GB.
+func parseSize(s string) (int64, error) {
+	upper := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		factor int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(upper, unit.suffix) {
+			upper = strings.TrimSuffix(upper, unit.suffix)
+			multiplier = unit.factor
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(upper), 64)
+	if err != nil {
+		return 0, err
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// formatSize renders a byte count with a binary unit suffix.
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1fGB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fMB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fKB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%dB", n)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/term"
+
+	"github.com/andev0x/capytrace.nvim/internal/replay"
+	"github.com/andev0x/capytrace.nvim/internal/store"
+)
+
+// replayFrame is the interval between redraws during playback.
+const replayFrame = 100 * time.Millisecond
+
+// replay keys decoded from terminal input.
+const (
+	keyLeft  = "left"
+	keyRight = "right"
+)
+
+// handleReplay plays a recorded session back in a full-screen terminal UI.
+func handleReplay() {
+	if len(os.Args) < 4 {
+		fmt.Fprintf(os.Stderr, "Usage: replay <session_id> <save_path> [--speed 4] [--max-gap 5s] [--no-skip-idle] [--paused]\n")
+		os.Exit(1)
+	}
+
+	sessionID, savePath := os.Args[2], os.Args[3]
+
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	speed := fs.Float64("speed", 4, "initial playback speed multiplier")
+	maxGap := fs.Duration("max-gap", 5*time.Second, "quiet periods longer than this are skipped")
+	noSkip := fs.Bool("no-skip-idle", false, "play idle periods in full")
+	paused := fs.Bool("paused", false, "start paused at the first event")
+	_ = fs.Parse(os.Args[4:])
+
+	session, err := store.Read(savePath, sessionID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load session: %v\n", err)
+		os.Exit(1)
+	}
+
+	player, err := replay.New(session, *maxGap)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot replay %s: %v\n", sessionID, err)
+		os.Exit(1)
+	}
+	player.SetSpeed(*speed)
+	player.SkipIdle = !*noSkip
+	player.Playing = !*paused
+
+	if err := runReplay(player); err != nil {
+		fmt.Fprintf(os.Stderr, "Replay failed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runReplay drives the player from keyboard input until the user quits.
+func runReplay(player *replay.Player) error {
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(in) || !term.IsTerminal(out) {
+		return fmt.Errorf("replay needs an interactive terminal")
+	}
+
+	state, err := term.MakeRaw(in)
+	if err != nil {
+		return err
+	}
+	// Alternate screen, hidden cursor; restored on exit
+	fmt.Print("\033[?1049h\033[?25l")
+	defer func() {
+		fmt.Print("\033[?25h\033[?1049l")
+		_ = term.Restore(in, state)
+	}()
+
+	keys := make(chan string)
+	go readKeys(keys)
+
+	ticker := time.NewTicker(replayFrame)
+	defer ticker.Stop()
+	last := time.Now()
+
+	for {
+		width, height, err := term.GetSize(out)
+		if err != nil {
+			width, height = 100, 30
+		}
+		fmt.Print("\033[H" + replay.Render(player, width, height))
+
+		select {
+		case key, ok := <-keys:
+			if !ok || !handleReplayKey(player, key) {
+				return nil
+			}
+		case now := <-ticker.C:
+			player.Advance(now.Sub(last))
+			last = now
+		}
+		if !player.Playing {
+			last = time.Now()
+		}
+	}
+}
+
+// handleReplayKey applies a key press and reports whether playback should continue.
+func handleReplayKey(player *replay.Player, key string) bool {
+	switch key {
+	case "q", "\x03", "\x1b":
+		return false
+	case " ", "p":
+		if player.Done() {
+			player.Seek(0)
+		}
+		player.Playing = !player.Playing
+	case "+", "=", "]":
+		player.Faster()
+	case "-", "_", "[":
+		player.Slower()
+	case keyRight, "l":
+		player.Step(1)
+	case keyLeft, "h":
+		player.Step(-1)
+	case "n":
+		player.Next("annotation")
+	case "N":
+		player.Prev("annotation")
+	case "d":
+		player.Next("lsp_diagnostic")
+	case "D":
+		player.Prev("lsp_diagnostic")
+	case "i":
+		player.SkipIdle = !player.SkipIdle
+	case "g":
+		player.Seek(0)
+	case "G":
+		player.Seek(len(player.Session.Events) - 1)
+	}
+	return true
+}
+
+// readKeys decodes raw terminal input into key names, turning arrow escape sequences into
+// keyLeft and keyRight. It stops when stdin is closed.
+func readKeys(keys chan<- string) {
+	buf := make([]byte, 16)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			close(keys)
+			return
+		}
+		input := buf[:n]
+		for len(input) > 0 {
+			if len(input) >= 3 && input[0] == 0x1b && input[1] == '[' {
+				switch input[2] {
+				case 'C':
+					keys <- keyRight
+				case 'D':
+					keys <- keyLeft
+				}
+				input = input[3:]
+				continue
+			}
+			keys <- string(input[:1])
+			input = input[1:]
+		}
+	}
+}
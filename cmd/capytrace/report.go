@@ -0,0 +1,128 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/aggregator"
+	"github.com/andev0x/capytrace.nvim/internal/exporter"
+	"github.com/andev0x/capytrace.nvim/internal/report"
+	"github.com/andev0x/capytrace.nvim/internal/store"
+)
+
+// rangeFlags are the --since/--until/--project options shared by multi-session commands.
+type rangeFlags struct {
+	since   string
+	until   string
+	project string
+}
+
+// bind registers the range options on a flag set.
+func (r *rangeFlags) bind(fs *flag.FlagSet) {
+	fs.StringVar(&r.since, "since", "", "first day to include (YYYY-MM-DD, RFC 3339 or relative like 7d)")
+	fs.StringVar(&r.until, "until", "", "last day to include (YYYY-MM-DD, RFC 3339 or relative like 1d)")
+	fs.StringVar(&r.project, "project", "", "only include sessions of this project (path or name)")
+}
+
+// query converts the parsed options into a store query.
+// A plain date given to --until includes that whole day.
+func (r *rangeFlags) query() (store.Query, error) {
+	q := store.Query{Project: r.project}
+	now := time.Now()
+
+	if r.since != "" {
+		t, _, err := parseRangeTime(r.since, now)
+		if err != nil {
+			return q, fmt.Errorf("invalid --since: %w", err)
+		}
+		q.Since = t
+	}
+	if r.until != "" {
+		t, isDate, err := parseRangeTime(r.until, now)
+		if err != nil {
+			return q, fmt.Errorf("invalid --until: %w", err)
+		}
+		if isDate {
+			t = t.AddDate(0, 0, 1)
+		}
+		q.Until = t
+	}
+	return q, nil
+}
+
+// parseRangeTime parses a local date, an RFC 3339 time or an age relative to now.
+// The boolean result reports whether the value was a plain date.
+func parseRangeTime(value string, now time.Time) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, true, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	age, err := parseAge(value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%q is not a date, RFC 3339 time or age", value)
+	}
+	return now.Add(-age), false, nil
+}
+
+// handleReport renders a rollup of many sessions grouped by day, week, project or file.
+func handleReport() {
+	if len(os.Args) < 3 {
+		fmt.Fprintf(os.Stderr, "Usage: report <save_path> [--since DATE] [--until DATE] [--project NAME] [--group-by day|week|project|file] [--format markdown|json|html] [--out FILE]\n")
+		os.Exit(1)
+	}
+
+	savePath := os.Args[2]
+
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	var rf rangeFlags
+	rf.bind(fs)
+	groupBy := fs.String("group-by", report.GroupByDay, "group sessions by day, week, project or file")
+	format := fs.String("format", "markdown", "output format: markdown, json or html")
+	out := fs.String("out", "", "write the report to this file instead of stdout")
+	_ = fs.Parse(os.Args[3:])
+
+	q, err := rf.query()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	sessions, err := store.LoadRange(savePath, q)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load sessions: %v\n", err)
+		os.Exit(1)
+	}
+
+	r, err := report.Build(sessions, *groupBy, aggregator.DefaultConfig())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to build report: %v\n", err)
+		os.Exit(1)
+	}
+	r.Project = q.Project
+	if !q.Since.IsZero() {
+		r.Since = &q.Since
+	}
+	if !q.Until.IsZero() {
+		r.Until = &q.Until
+	}
+
+	content, err := exporter.RenderReport(r, *format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to render report: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *out == "" {
+		fmt.Print(string(content))
+		return
+	}
+	if err := os.WriteFile(*out, content, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write report: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Report written: %s\n", *out)
+}
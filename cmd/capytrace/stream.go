@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/recorder"
+	"github.com/andev0x/capytrace.nvim/internal/stream"
+)
+
+// serveStream publishes the daemon's recorded events on addr in the background.
+// The listener is opened before returning so address errors are reported immediately.
+func serveStream(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	hub := stream.NewHub(stream.DefaultHistory, stream.DefaultBuffer)
+	recorder.SetPublisher(hub)
+
+	server := &http.Server{
+		Handler:           stream.Handler(hub),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			fmt.Fprintf(os.Stderr, "Event stream stopped: %v\n", err)
+		}
+	}()
+
+	// stdout is reserved for daemon responses
+	fmt.Fprintf(os.Stderr, "CapyTrace event stream: http://%s/events (SSE), ws://%s/ws\n", listener.Addr(), listener.Addr())
+	return nil
+}
@@ -0,0 +1,419 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/aggregator"
+	"github.com/andev0x/capytrace.nvim/internal/exporter"
+	"github.com/andev0x/capytrace.nvim/internal/models"
+	"github.com/andev0x/capytrace.nvim/internal/report"
+	"github.com/andev0x/capytrace.nvim/internal/store"
+	"github.com/andev0x/capytrace.nvim/internal/stream"
+)
+
+// journalPoll is how often tail checks the session's raw JSON for new events.
+const journalPoll = 500 * time.Millisecond
+
+// ANSI colors used by tail.
+const (
+	colorReset   = "\033[0m"
+	colorDim     = "\033[2m"
+	colorRed     = "\033[31m"
+	colorGreen   = "\033[32m"
+	colorYellow  = "\033[33m"
+	colorMagenta = "\033[35m"
+	colorCyan    = "\033[36m"
+)
+
+// tailPrinter writes events as compact colorized lines or JSON lines.
+type tailPrinter struct {
+	w           io.Writer
+	color       bool
+	json        bool
+	projectPath string
+}
+
+// handleTail follows a session from its journal or the daemon stream and prints each event.
+func handleTail() {
+	if len(os.Args) < 4 {
+		fmt.Fprintf(os.Stderr, "Usage: tail <session_id> <save_path> [--stream URL] [--type t1,t2] [--file pattern] [-n 10] [--summary [--interval 3s]] [--json] [--no-color]\n")
+		os.Exit(1)
+	}
+
+	sessionID, savePath := os.Args[2], os.Args[3]
+
+	fs := flag.NewFlagSet("tail", flag.ExitOnError)
+	streamURL := fs.String("stream", "", "follow the daemon stream at this URL (e.g. http://127.0.0.1:7879) instead of the journal")
+	types := fs.String("type", "", "only show these comma-separated event types")
+	files := fs.String("file", "", "only show events for these comma-separated files or patterns (e.g. *.go)")
+	lines := fs.Int("n", 10, "number of past events to show first (journal mode)")
+	summary := fs.Bool("summary", false, "redraw live velocity, focus and idle counters instead of printing events")
+	interval := fs.Duration("interval", 3*time.Second, "redraw interval for --summary")
+	asJSON := fs.Bool("json", false, "print one JSON object per line")
+	noColor := fs.Bool("no-color", false, "disable colors")
+	_ = fs.Parse(os.Args[4:])
+
+	filter := stream.Filter{
+		Session: sessionID,
+		Types:   stream.SplitList(*types),
+		Files:   stream.SplitList(*files),
+	}
+
+	out := &tailPrinter{
+		w:     os.Stdout,
+		color: !*noColor && !*asJSON && isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == "",
+		json:  *asJSON,
+	}
+	if session, err := store.Read(savePath, sessionID); err == nil {
+		out.projectPath = session.ProjectPath
+	}
+
+	var err error
+	switch {
+	case *summary:
+		err = tailSummary(sessionID, savePath, *interval, out)
+	case *streamURL != "":
+		err = tailStream(*streamURL, filter, out)
+	default:
+		err = tailJournal(sessionID, savePath, filter, *lines, out)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tail: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// tailJournal polls the session's raw JSON and prints events as they are appended.
+// It returns once the session has ended.
+func tailJournal(sessionID, savePath string, filter stream.Filter, backlog int, out *tailPrinter) error {
+	session, err := store.Read(savePath, sessionID)
+	if err != nil {
+		return err
+	}
+
+	// Show the last few matching events first
+	var recent []int
+	for i, event := range session.Events {
+		if filter.Match(journalMessage(sessionID, i, event)) {
+			recent = append(recent, i)
+		}
+	}
+	if backlog >= 0 && len(recent) > backlog {
+		recent = recent[len(recent)-backlog:]
+	}
+	for _, i := range recent {
+		out.print(journalMessage(sessionID, i, session.Events[i]))
+	}
+
+	printed := len(session.Events)
+	path := filepath.Join(savePath, sessionID+"_raw.json")
+	var lastMod time.Time
+	if stat, err := os.Stat(path); err == nil {
+		lastMod = stat.ModTime()
+	}
+
+	for session.Active {
+		time.Sleep(journalPoll)
+
+		stat, err := os.Stat(path)
+		if err != nil || !stat.ModTime().After(lastMod) {
+			continue
+		}
+
+		next, err := store.Read(savePath, sessionID)
+		if err != nil {
+			// The recorder may be halfway through rewriting the file; try again next poll
+			continue
+		}
+		lastMod = stat.ModTime()
+		session = next
+
+		for i := printed; i < len(session.Events); i++ {
+			msg := journalMessage(sessionID, i, session.Events[i])
+			if filter.Match(msg) {
+				out.print(msg)
+			}
+		}
+		if len(session.Events) > printed {
+			printed = len(session.Events)
+		}
+	}
+
+	return nil
+}
+
+// journalMessage wraps a journal event like a stream message; Seq is the 1-based event index.
+func journalMessage(sessionID string, index int, event models.Event) stream.Message {
+	return stream.Message{
+		Seq:       uint64(index + 1),
+		Kind:      stream.KindEvent,
+		SessionID: sessionID,
+		Time:      event.Timestamp,
+		Event:     &event,
+	}
+}
+
+// tailStream follows the daemon's Server-Sent Events stream, reconnecting and resuming
+// from the last sequence number when the connection drops. It returns once the session has ended.
+func tailStream(base string, filter stream.Filter, out *tailPrinter) error {
+	endpoint, err := url.Parse(strings.TrimSuffix(base, "/") + "/events")
+	if err != nil {
+		return fmt.Errorf("invalid stream URL: %w", err)
+	}
+
+	// session_end is always requested so tail knows when to stop. It has no file, so the
+	// file filter is applied locally rather than by the daemon.
+	types := filter.Types
+	if len(types) > 0 {
+		types = append(append([]string{}, types...), "session_end")
+	}
+
+	var since uint64
+	for {
+		params := url.Values{}
+		params.Set("session", filter.Session)
+		if len(types) > 0 {
+			params.Set("types", strings.Join(types, ","))
+		}
+		if since > 0 {
+			params.Set("since", strconv.FormatUint(since, 10))
+		}
+		endpoint.RawQuery = params.Encode()
+
+		ended, last, err := readStream(endpoint.String(), filter, out)
+		if last > since {
+			since = last
+		}
+		if ended {
+			return nil
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "tail: %v; reconnecting\n", err)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// readStream reads one SSE connection. It reports whether the session ended and the last sequence seen.
+func readStream(endpoint string, filter stream.Filter, out *tailPrinter) (bool, uint64, error) {
+	resp, err := http.Get(endpoint)
+	if err != nil {
+		return false, 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return false, 0, fmt.Errorf("stream returned %s", resp.Status)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	var last uint64
+	var kind, data string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			kind = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		case line == "" && data != "":
+			switch kind {
+			case "gap":
+				fmt.Fprintf(os.Stderr, "tail: some events were missed\n")
+			case "dropped":
+				fmt.Fprintf(os.Stderr, "tail: fell behind the stream\n")
+			case stream.KindEvent:
+				var msg stream.Message
+				if err := json.Unmarshal([]byte(data), &msg); err == nil && msg.Event != nil {
+					last = msg.Seq
+					if filter.Match(msg) {
+						out.print(msg)
+					}
+					if msg.Event.Type == "session_end" {
+						return true, last, nil
+					}
+				}
+			}
+			kind, data = "", ""
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, last, err
+	}
+	return false, last, fmt.Errorf("stream closed")
+}
+
+// tailSummary redraws live counters computed from the session journal until the session ends.
+func tailSummary(sessionID, savePath string, interval time.Duration, out *tailPrinter) error {
+	agg := aggregator.New(aggregator.DefaultConfig())
+	redraw := isTerminal(os.Stdout) && !out.json
+
+	if _, err := store.Read(savePath, sessionID); err != nil {
+		return err
+	}
+
+	for {
+		// The journal may be mid-rewrite; retry instead of giving up
+		session, err := store.Read(savePath, sessionID)
+		if err != nil {
+			time.Sleep(journalPoll)
+			continue
+		}
+
+		_, analytics := agg.AggregateSession(session)
+		if out.json {
+			data, _ := json.Marshal(stream.Message{Kind: stream.KindAnalytics, SessionID: sessionID, Time: time.Now(), Analytics: analytics})
+			fmt.Fprintln(out.w, string(data))
+		} else {
+			if redraw {
+				fmt.Fprint(out.w, "\033[H\033[2J")
+			}
+			out.printSummary(session, analytics)
+		}
+
+		if !session.Active {
+			return nil
+		}
+		time.Sleep(interval)
+	}
+}
+
+// print writes one event line.
+func (p *tailPrinter) print(msg stream.Message) {
+	if p.json {
+		data, _ := json.Marshal(msg)
+		fmt.Fprintln(p.w, string(data))
+		return
+	}
+
+	event := *msg.Event
+	color := p.colorFor(event)
+	location := p.location(event)
+	detail := exporter.DetailFor(event)
+	if detail == "" && event.Data.LineText != "" {
+		detail = exporter.TruncateText(80, strings.TrimSpace(event.Data.LineText))
+	}
+	if event.Data.ExitCode != nil {
+		detail += fmt.Sprintf(" (exit %d)", *event.Data.ExitCode)
+	}
+
+	fmt.Fprintf(p.w, "%s %s %s%-16s%s %s%s\n",
+		p.paint(colorDim, event.Timestamp.Local().Format("15:04:05")),
+		exporter.EmojiFor(event.Type),
+		color, event.Type, p.reset(color),
+		location,
+		detail)
+}
+
+// printSummary writes the live counters for --summary.
+func (p *tailPrinter) printSummary(session *models.Session, analytics *models.SessionAnalytics) {
+	state := p.paint(colorGreen, "recording")
+	if !session.Active {
+		state = p.paint(colorDim, "ended")
+	}
+
+	var current string
+	var lastEvent time.Time
+	counts := make(map[string]int)
+	for _, event := range session.Events {
+		counts[event.Type]++
+		if event.Data.Filename != "" && event.Type != "lsp_diagnostic" {
+			current = event.Data.Filename
+		}
+		lastEvent = event.Timestamp
+	}
+
+	fmt.Fprintf(p.w, "🦦 %s  %s  %s\n", session.ID, state, p.paint(colorDim, time.Now().Format("15:04:05")))
+	fmt.Fprintf(p.w, "  Elapsed      %s\n", formatElapsed(report.SessionDuration(session)))
+	fmt.Fprintf(p.w, "  Events       %d (%d edits, %d commands, %d notes, %d diagnostics)\n",
+		len(session.Events), counts["file_edit"], counts["terminal_command"], counts["annotation"], counts["lsp_diagnostic"])
+	fmt.Fprintf(p.w, "  Velocity     %.2f avg · %.2f peak ticks/s\n", analytics.AverageVelocity, analytics.PeakVelocity)
+	fmt.Fprintf(p.w, "  Focus        %.0f%%\n", analytics.FocusRatio*100)
+	fmt.Fprintf(p.w, "  Flow         %s\n", formatElapsed(report.FlowTime(analytics)))
+	fmt.Fprintf(p.w, "  Idle         %s in %d gaps\n", formatElapsed(analytics.TotalIdleTime), len(analytics.IdleGaps))
+	if current != "" {
+		fmt.Fprintf(p.w, "  Current file %s\n", aggregator.RelativePath(p.projectPath, current))
+	}
+	if session.Active && !lastEvent.IsZero() {
+		fmt.Fprintf(p.w, "  Last event   %s ago\n", formatElapsed(time.Since(lastEvent)))
+	}
+	fmt.Fprintln(p.w)
+}
+
+// colorFor picks the line color for an event.
+func (p *tailPrinter) colorFor(event models.Event) string {
+	if !p.color {
+		return ""
+	}
+	switch event.Type {
+	case "file_edit", "file_open":
+		return colorGreen
+	case "annotation":
+		return colorYellow
+	case "lsp_diagnostic":
+		if strings.EqualFold(event.Data.Level, "error") {
+			return colorRed
+		}
+		return colorYellow
+	case "terminal_command":
+		if report.IsFailedCommand(event) {
+			return colorRed
+		}
+		return colorCyan
+	case "session_start", "session_end", "session_resume", "session_gap":
+		return colorMagenta
+	default:
+		return colorDim
+	}
+}
+
+// location formats file:line relative to the project.
+func (p *tailPrinter) location(event models.Event) string {
+	if event.Data.Filename == "" {
+		return ""
+	}
+	loc := aggregator.RelativePath(p.projectPath, event.Data.Filename)
+	if event.Data.Line > 0 {
+		loc += ":" + strconv.Itoa(event.Data.Line)
+	}
+	return p.paint(colorDim, loc) + " "
+}
+
+func (p *tailPrinter) paint(color, text string) string {
+	if !p.color {
+		return text
+	}
+	return color + text + colorReset
+}
+
+func (p *tailPrinter) reset(color string) string {
+	if color == "" {
+		return ""
+	}
+	return colorReset
+}
+
+// isTerminal reports whether f is attached to a terminal.
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+// formatElapsed renders a duration rounded to seconds, e.g. 1h02m03s.
+func formatElapsed(d time.Duration) string {
+	return d.Round(time.Second).String()
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/andev0x/capytrace.nvim/internal/anonymize"
+	"github.com/andev0x/capytrace.nvim/internal/exporter"
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+// handleTemplate manages the Markdown report templates.
+func handleTemplate() {
+	if len(os.Args) < 4 || os.Args[2] != "dump" {
+		fmt.Fprintf(os.Stderr, "Usage: template dump <dir> [--force]\n")
+		os.Exit(1)
+	}
+
+	dir := os.Args[3]
+
+	fs := flag.NewFlagSet("template dump", flag.ExitOnError)
+	force := fs.Bool("force", false, "overwrite existing templates")
+	_ = fs.Parse(os.Args[4:])
+
+	if err := dumpTemplates(dir, *force); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to dump templates: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// dumpTemplates writes the embedded default templates into dir.
+func dumpTemplates(dir string, force bool) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	templates := exporter.DefaultTemplates()
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil && !force {
+			return fmt.Errorf("%s already exists (use --force to overwrite)", path)
+		}
+	}
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, templates[name], 0644); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %s\n", path)
+	}
+	return nil
+}
+
+// parseExportOptions reads the export flags that may follow the positional args of
+// start and end: --template DIR, --anonymize and repeatable --option key=value for
+// exporter options.
+func parseExportOptions(command string, args []string) (map[string]string, error) {
+	options := make(map[string]string)
+
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	templateDir := fs.String("template", "", "directory with session.md / summary.md / session.html overriding the built-in templates")
+	anonymized := fs.Bool("anonymize", false, "write pseudonymized exports to <save_path>/anonymized instead")
+	fs.Func("option", "exporter option as key=value (repeatable)", func(value string) error {
+		key, val, ok := strings.Cut(value, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("expected key=value, got %q", value)
+		}
+		options[key] = val
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *templateDir != "" {
+		dir, err := filepath.Abs(*templateDir)
+		if err != nil {
+			return nil, err
+		}
+		options[exporter.OptionTemplateDir] = dir
+	}
+	if *anonymized {
+		options[anonymize.OptionAnonymize] = "true"
+	}
+	return options, nil
+}
+
+// applyExportOptions stores export options on a session, replacing earlier values.
+func applyExportOptions(session *models.Session, options map[string]string) {
+	if len(options) == 0 {
+		return
+	}
+	if session.ExportOptions == nil {
+		session.ExportOptions = make(map[string]string)
+	}
+	for key, value := range options {
+		session.ExportOptions[key] = value
+	}
+}
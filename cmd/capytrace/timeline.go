@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/aggregator"
+	"github.com/andev0x/capytrace.nvim/internal/exporter"
+	"github.com/andev0x/capytrace.nvim/internal/models"
+	"github.com/andev0x/capytrace.nvim/internal/store"
+	"github.com/andev0x/capytrace.nvim/internal/timeline"
+)
+
+// handleMerge combines several sessions into a new one with a single timeline.
+func handleMerge() {
+	usage := "Usage: merge <session_id> <session_id>... <save_path> --into <new_id>\n"
+	var positional []string
+	var newID string
+	for i := 2; i < len(os.Args); i++ {
+		if os.Args[i] == "--into" && i+1 < len(os.Args) {
+			newID = os.Args[i+1]
+			i++
+			continue
+		}
+		positional = append(positional, os.Args[i])
+	}
+
+	if newID == "" || len(positional) < 3 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(1)
+	}
+	sourceIDs, savePath := positional[:len(positional)-1], positional[len(positional)-1]
+
+	if err := store.ValidateID(newID); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to merge sessions: %v\n", err)
+		os.Exit(1)
+	}
+
+	if store.Exists(savePath, newID) {
+		fmt.Fprintf(os.Stderr, "Session %s already exists\n", newID)
+		os.Exit(1)
+	}
+
+	var sources []*models.Session
+	seen := make(map[string]bool)
+	for _, id := range sourceIDs {
+		if seen[id] {
+			fmt.Fprintf(os.Stderr, "Session %s is listed more than once\n", id)
+			os.Exit(1)
+		}
+		seen[id] = true
+		if err := ensureInactive(id, savePath); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to merge sessions: %v\n", err)
+			os.Exit(1)
+		}
+		session, err := store.Read(savePath, id)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load session %s: %v\n", id, err)
+			os.Exit(1)
+		}
+		sources = append(sources, session)
+	}
+
+	merged, err := timeline.Merge(newID, sources)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to merge sessions: %v\n", err)
+		os.Exit(1)
+	}
+	merged.SavePath = savePath
+
+	if err := writeDerivedSession(merged); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to save merged session: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Merged %d sessions into %s (%d events)\n", len(sources), newID, len(merged.Events))
+}
+
+// splitSuggestion is a candidate split point offered to the user.
+type splitSuggestion struct {
+	At       time.Time `json:"at"`
+	Gap      string    `json:"gap"`
+	GapStart time.Time `json:"gap_start"`
+}
+
+// handleSplit divides a session in two, or lists suggested split points.
+func handleSplit() {
+	if len(os.Args) < 4 {
+		fmt.Fprintf(os.Stderr, "Usage: split <session_id> <save_path> --at <time|annotation_index> [--into first_id,second_id]\n")
+		fmt.Fprintf(os.Stderr, "       split <session_id> <save_path> --suggest [--min-gap 30m] [--json]\n")
+		os.Exit(1)
+	}
+
+	sessionID, savePath := os.Args[2], os.Args[3]
+
+	fs := flag.NewFlagSet("split", flag.ExitOnError)
+	at := fs.String("at", "", "split point: annotation index, RFC 3339 time or HH:MM[:SS]")
+	into := fs.String("into", "", "comma-separated IDs for the two new sessions")
+	suggest := fs.Bool("suggest", false, "list split points at long idle gaps")
+	minGap := fs.Duration("min-gap", 30*time.Minute, "minimum idle gap for suggestions")
+	asJSON := fs.Bool("json", false, "print suggestions as JSON")
+	_ = fs.Parse(os.Args[4:])
+
+	if *suggest {
+		suggestions, err := suggestSplits(sessionID, savePath, *minGap)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to suggest split points: %v\n", err)
+			os.Exit(1)
+		}
+		if *asJSON {
+			encoded, err := encodeSuggestions(suggestions)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to encode suggestions: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(encoded)
+			return
+		}
+		if len(suggestions) == 0 {
+			fmt.Printf("No idle gaps longer than %s\n", *minGap)
+			return
+		}
+		for _, s := range suggestions {
+			fmt.Printf("%s  after %s idle\n", s.At.Local().Format("2006-01-02 15:04:05"), s.Gap)
+		}
+		return
+	}
+
+	args := []string{sessionID, savePath, *at}
+	if *into != "" {
+		args = append(args, strings.Split(*into, ",")...)
+	}
+
+	message, err := runSplit(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to split session: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(message)
+}
+
+// runSplit splits a session given [session_id, save_path, at, first_id?, second_id?].
+// The original session is left untouched, and a part is removed again when the other
+// cannot be written.
+func runSplit(args []string) (string, error) {
+	if len(args) < 3 || args[2] == "" {
+		return "", fmt.Errorf("split requires a session, save path and split point")
+	}
+	sessionID, savePath := args[0], args[1]
+
+	firstID, secondID := sessionID+"_part1", sessionID+"_part2"
+	if len(args) >= 5 {
+		firstID, secondID = args[3], args[4]
+	}
+
+	if err := ensureInactive(sessionID, savePath); err != nil {
+		return "", err
+	}
+	if firstID == secondID {
+		return "", fmt.Errorf("the two parts need different IDs, got %s twice", firstID)
+	}
+	for _, id := range []string{firstID, secondID} {
+		if err := store.ValidateID(id); err != nil {
+			return "", err
+		}
+		if store.Exists(savePath, id) {
+			return "", fmt.Errorf("session %s already exists", id)
+		}
+	}
+
+	session, err := store.Read(savePath, sessionID)
+	if err != nil {
+		return "", err
+	}
+
+	at, err := timeline.ParseSplitPoint(session, args[2])
+	if err != nil {
+		return "", err
+	}
+
+	first, second, err := timeline.Split(session, at, firstID, secondID)
+	if err != nil {
+		return "", err
+	}
+
+	first.SavePath, second.SavePath = savePath, savePath
+	if err := writeDerivedSession(first); err != nil {
+		removeDerivedSession(first)
+		return "", err
+	}
+	if err := writeDerivedSession(second); err != nil {
+		removeDerivedSession(second)
+		removeDerivedSession(first)
+		return "", err
+	}
+
+	return fmt.Sprintf("Session split at %s into %s and %s", at.Local().Format("15:04:05"), firstID, secondID), nil
+}
+
+// suggestSplits returns split points at idle gaps of at least minGap, longest first.
+func suggestSplits(sessionID, savePath string, minGap time.Duration) ([]splitSuggestion, error) {
+	session, err := store.Read(savePath, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var suggestions []splitSuggestion
+	for _, gap := range timeline.SuggestSplits(session, minGap) {
+		suggestions = append(suggestions, splitSuggestion{
+			At:       gap.EndTime,
+			Gap:      gap.Duration.Round(time.Second).String(),
+			GapStart: gap.StartTime,
+		})
+	}
+	return suggestions, nil
+}
+
+// encodeSuggestions renders split suggestions as JSON for daemon clients.
+func encodeSuggestions(suggestions []splitSuggestion) (string, error) {
+	if suggestions == nil {
+		suggestions = []splitSuggestion{}
+	}
+	data, err := json.Marshal(suggestions)
+	return string(data), err
+}
+
+// writeDerivedSession saves a session produced from others, exports it with every format
+// in its output format list and export options, as ending a session does, and
+// regenerates SESSION_SUMMARY.md.
+func writeDerivedSession(session *models.Session) error {
+	if err := store.Write(session.SavePath, session); err != nil {
+		return err
+	}
+	if _, err := exporter.Collect(exporter.Run(session, session.SavePath, store.DataDir())); err != nil {
+		return err
+	}
+	return exporter.NewSmartMarkdownExporter(aggregator.DefaultConfig()).Export(session, session.SavePath)
+}
+
+// removeDerivedSession deletes what writeDerivedSession wrote for a session, so a failed
+// split leaves no half of it behind.
+func removeDerivedSession(session *models.Session) {
+	if info, err := store.Stat(session.SavePath, session.ID); err == nil {
+		_ = store.Delete(info)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/andev0x/capytrace.nvim/internal/exporter"
+	"github.com/andev0x/capytrace.nvim/internal/store"
+	"github.com/andev0x/capytrace.nvim/internal/wakatime"
+)
+
+// handleWakaTime converts sessions to WakaTime heartbeats and heartbeat dumps to sessions.
+func handleWakaTime() {
+	if len(os.Args) < 5 || (os.Args[2] != "export" && os.Args[2] != "import") {
+		fmt.Fprintf(os.Stderr, "Usage: wakatime export <session_id> <save_path> [--out FILE] [--url URL]\n")
+		fmt.Fprintf(os.Stderr, "       wakatime import <dump.json> <save_path> [--idle DURATION]\n")
+		os.Exit(1)
+	}
+
+	if os.Args[2] == "export" {
+		wakaTimeExport(os.Args[3], os.Args[4])
+	} else {
+		wakaTimeImport(os.Args[3], os.Args[4])
+	}
+}
+
+// wakaTimeExport writes a session's heartbeats to a file and optionally sends them to a server.
+func wakaTimeExport(sessionID, savePath string) {
+	fs := flag.NewFlagSet("wakatime export", flag.ExitOnError)
+	out := fs.String("out", "", "write the heartbeats to this file instead of {session_id}.wakatime.json")
+	url := fs.String("url", "", "send the heartbeats to this WakaTime-compatible API, using $"+wakatime.APIKeyEnv)
+	_ = fs.Parse(os.Args[5:])
+
+	session, err := store.Read(savePath, sessionID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load session: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Only send when asked to on the command line, not to the session's configured server
+	waka := &exporter.WakaTimeExporter{URL: *url, Offline: *url == "", Out: *out}
+	if err := waka.Export(session, savePath); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to export heartbeats: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Heartbeats written: %s\n", waka.Artifacts(session, savePath)[0])
+	if *url != "" {
+		fmt.Printf("Heartbeats sent to %s\n", *url)
+	}
+}
+
+// wakaTimeImport turns a heartbeat dump into sessions, skipping sessions already imported.
+func wakaTimeImport(dumpPath, savePath string) {
+	fs := flag.NewFlagSet("wakatime import", flag.ExitOnError)
+	idle := fs.Duration("idle", wakatime.DefaultIdleTimeout, "start a new session after this long without heartbeats")
+	_ = fs.Parse(os.Args[5:])
+
+	data, err := os.ReadFile(dumpPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read dump: %v\n", err)
+		os.Exit(1)
+	}
+	heartbeats, err := wakatime.Parse(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to parse %s: %v\n", filepath.Base(dumpPath), err)
+		os.Exit(1)
+	}
+
+	if err := os.MkdirAll(savePath, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create save path: %v\n", err)
+		os.Exit(1)
+	}
+
+	imported, skipped := 0, 0
+	for _, session := range wakatime.ToSessions(heartbeats, *idle) {
+		if store.Exists(savePath, session.ID) {
+			skipped++
+			continue
+		}
+		if err := store.Write(savePath, session); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write session %s: %v\n", session.ID, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Imported %s (%d events)\n", session.ID, len(session.Events))
+		imported++
+	}
+	fmt.Printf("Imported %d sessions from %d heartbeats (%d already present)\n", imported, len(heartbeats), skipped)
+}
@@ -2,7 +2,11 @@ module github.com/andev0x/capytrace.nvim
 
 go 1.24.0
 
-require modernc.org/sqlite v1.44.0
+require (
+	github.com/klauspost/compress v1.18.0
+	golang.org/x/term v0.36.0
+	modernc.org/sqlite v1.44.0
+)
 
 require (
 	github.com/dustin/go-humanize v1.0.1 // indirect
@@ -82,6 +82,12 @@ func (a *Aggregator) buildActivityBlocks(events []models.Event) []models.Activit
 				blocks = append(blocks, *currentBlock)
 				currentBlock = nil
 			}
+			// A gap between merged sessions always ends the current block
+			if currentBlock != nil && event.Type == "session_gap" {
+				currentBlock.ClosedBy = "session_gap"
+				blocks = append(blocks, *currentBlock)
+				currentBlock = nil
+			}
 			lastEventTime = event.Timestamp
 			continue
 		}
@@ -187,7 +193,7 @@ func (a *Aggregator) computeAnalytics(session *models.Session, blocks []models.A
 	}
 
 	// Calculate focus ratio and idle gaps
-	analytics.IdleGaps = a.findIdleGaps(session.Events)
+	analytics.IdleGaps = a.FindIdleGaps(session.Events)
 	for _, gap := range analytics.IdleGaps {
 		analytics.TotalIdleTime += gap.Duration
 	}
@@ -201,11 +207,16 @@ func (a *Aggregator) computeAnalytics(session *models.Session, blocks []models.A
 	return analytics
 }
 
-// findIdleGaps identifies periods of inactivity > 5 minutes.
-func (a *Aggregator) findIdleGaps(events []models.Event) []models.IdleGap {
+// FindIdleGaps identifies periods of inactivity longer than the configured IdleThreshold (default 5 minutes).
+// Time following a session_gap marker is a break between merged sessions, not idle time.
+func (a *Aggregator) FindIdleGaps(events []models.Event) []models.IdleGap {
 	var gaps []models.IdleGap
 
 	for i := 1; i < len(events); i++ {
+		if events[i-1].Type == "session_gap" {
+			continue
+		}
+
 		timeBetween := events[i].Timestamp.Sub(events[i-1].Timestamp)
 
 		if timeBetween > a.config.IdleThreshold {
@@ -223,11 +234,12 @@ func (a *Aggregator) findIdleGaps(events []models.Event) []models.IdleGap {
 // calculateFocusMetrics computes focus ratio and distraction time.
 func (a *Aggregator) calculateFocusMetrics(events []models.Event, analytics *models.SessionAnalytics) {
 	var lastEventTime time.Time
+	var lastEventType string
 	var currentFile string
 
 	for _, event := range events {
-		// Calculate time spent on previous file
-		if !lastEventTime.IsZero() && currentFile != "" {
+		// Calculate time spent on previous file (breaks between merged sessions don't count)
+		if !lastEventTime.IsZero() && currentFile != "" && lastEventType != "session_gap" {
 			duration := event.Timestamp.Sub(lastEventTime)
 
 			if a.isDistractionFile(currentFile) {
@@ -242,6 +254,7 @@ func (a *Aggregator) calculateFocusMetrics(events []models.Event, analytics *mod
 			currentFile = event.Data.Filename
 		}
 		lastEventTime = event.Timestamp
+		lastEventType = event.Type
 	}
 
 	// Calculate focus ratio
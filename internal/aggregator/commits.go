@@ -0,0 +1,149 @@
+package aggregator
+
+import (
+	"strings"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+// Commit is a git commit made from a recorded terminal command.
+type Commit struct {
+	Time    time.Time `json:"time"`
+	Message string    `json:"message,omitempty"` // Empty when the message was written in an editor
+	Command string    `json:"command"`
+}
+
+// ExtractCommits returns the `git commit` invocations found in terminal commands, in order.
+// Commands that exited with a non-zero status are skipped. For example, the command
+// `git add . && git commit -m "Fix parser"` yields one commit with the message "Fix parser".
+func ExtractCommits(events []models.Event) []Commit {
+	var commits []Commit
+	for _, event := range events {
+		if event.Type != "terminal_command" || !strings.Contains(event.Data.Command, "commit") {
+			continue
+		}
+		if event.Data.ExitCode != nil && *event.Data.ExitCode != 0 {
+			continue
+		}
+		for _, args := range splitShell(event.Data.Command) {
+			if message, ok := commitMessage(args); ok {
+				commits = append(commits, Commit{Time: event.Timestamp, Message: message, Command: event.Data.Command})
+			}
+		}
+	}
+	return commits
+}
+
+// commitMessage reports whether args run `git commit` and returns the message given with
+// -m/--message; several messages are joined into paragraphs as git does.
+func commitMessage(args []string) (string, bool) {
+	i := 0
+	for i < len(args) && strings.Contains(args[i], "=") && !strings.HasPrefix(args[i], "-") {
+		i++ // Leading environment assignments
+	}
+	if i >= len(args) || args[i] != "git" {
+		return "", false
+	}
+
+	// Global options before the subcommand; -C and -c take a value
+	for i++; i < len(args) && strings.HasPrefix(args[i], "-"); i++ {
+		if args[i] == "-C" || args[i] == "-c" {
+			i++
+		}
+	}
+	if i >= len(args) || args[i] != "commit" {
+		return "", false
+	}
+
+	var messages []string
+	for i++; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--message" || arg == "-m":
+			if i+1 < len(args) {
+				i++
+				messages = append(messages, args[i])
+			}
+		case strings.HasPrefix(arg, "--message="):
+			messages = append(messages, strings.TrimPrefix(arg, "--message="))
+		case strings.HasPrefix(arg, "-m"):
+			messages = append(messages, strings.TrimPrefix(arg, "-m"))
+		case len(arg) > 2 && arg[0] == '-' && arg[1] != '-' && strings.HasSuffix(arg, "m"):
+			// Combined short flags such as -am
+			if i+1 < len(args) {
+				i++
+				messages = append(messages, args[i])
+			}
+		}
+	}
+	return strings.Join(messages, "\n\n"), true
+}
+
+// splitShell splits a command line into the argument lists of its commands, honouring
+// single and double quotes and backslash escapes, and separating commands at ;, && and ||.
+func splitShell(line string) [][]string {
+	var commands [][]string
+	var args []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+
+	endWord := func() {
+		if inWord {
+			args = append(args, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	endCommand := func() {
+		endWord()
+		if len(args) > 0 {
+			commands = append(commands, args)
+			args = nil
+		}
+	}
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			switch {
+			case r == '"':
+				quote = 0
+			case r == '\\' && i+1 < len(runes) && strings.ContainsRune(`"\$`+"`", runes[i+1]):
+				i++
+				word.WriteRune(runes[i])
+			default:
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == '\\' && i+1 < len(runes):
+			i++
+			word.WriteRune(runes[i])
+			inWord = true
+		case r == ';' || r == '\n':
+			endCommand()
+		case (r == '&' || r == '|') && i+1 < len(runes) && runes[i+1] == r:
+			i++
+			endCommand()
+		case r == ' ' || r == '\t':
+			endWord()
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	endCommand()
+
+	return commands
+}
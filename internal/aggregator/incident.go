@@ -0,0 +1,128 @@
+package aggregator
+
+import (
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+// Incident phases marked by annotation tags.
+const (
+	PhaseDetection  = "detection"
+	PhaseMitigation = "mitigation"
+	PhaseResolution = "resolution"
+)
+
+// phaseTags maps annotation #hashtags to the incident phase they mark.
+var phaseTags = map[string]string{
+	"detected":   PhaseDetection,
+	"detection":  PhaseDetection,
+	"detect":     PhaseDetection,
+	"alert":      PhaseDetection,
+	"mitigated":  PhaseMitigation,
+	"mitigation": PhaseMitigation,
+	"mitigate":   PhaseMitigation,
+	"workaround": PhaseMitigation,
+	"resolved":   PhaseResolution,
+	"resolution": PhaseResolution,
+	"resolve":    PhaseResolution,
+}
+
+// IncidentMarker is an annotation that marks an incident phase.
+type IncidentMarker struct {
+	Phase string    `json:"phase"`
+	Time  time.Time `json:"time"`
+	Note  string    `json:"note"`
+}
+
+// Incident holds the phase markers of a session and the times between them.
+type Incident struct {
+	Markers []IncidentMarker `json:"markers"`
+
+	Detected  time.Time `json:"detected"`            // First detection marker, else the session start
+	Mitigated time.Time `json:"mitigated,omitempty"` // First mitigation marker; zero when none
+	Resolved  time.Time `json:"resolved,omitempty"`  // Last resolution marker, else the session end; zero while unresolved
+
+	// DetectedByMarker and ResolvedByMarker report whether Detected and Resolved come from
+	// annotation tags rather than the session start and end.
+	DetectedByMarker bool `json:"detected_by_marker"`
+	ResolvedByMarker bool `json:"resolved_by_marker"`
+
+	TimeToMitigation time.Duration `json:"time_to_mitigation"` // Detected to Mitigated
+	TimeToResolution time.Duration `json:"time_to_resolution"` // Detected to Resolved
+	ActiveResolution time.Duration `json:"active_resolution"`  // TimeToResolution minus idle gaps
+}
+
+// PhaseOf returns the incident phase an annotation note marks, or "" when it has no phase tag.
+func PhaseOf(note string) string {
+	for _, tag := range ParseTags(note) {
+		if phase, ok := phaseTags[tag]; ok {
+			return phase
+		}
+	}
+	return ""
+}
+
+// AnalyzeIncident derives incident phases from #detected, #mitigated and #resolved style
+// annotation tags. Without a detection marker the incident starts with the session, and
+// without a resolution marker it ends with the session. Idle gaps found by FindIdleGaps
+// are excluded from ActiveResolution.
+func (a *Aggregator) AnalyzeIncident(session *models.Session) Incident {
+	incident := Incident{Markers: []IncidentMarker{}}
+
+	for _, event := range session.Events {
+		if event.Type != "annotation" {
+			continue
+		}
+		phase := PhaseOf(event.Data.Note)
+		if phase == "" {
+			continue
+		}
+		incident.Markers = append(incident.Markers, IncidentMarker{Phase: phase, Time: event.Timestamp, Note: event.Data.Note})
+
+		switch phase {
+		case PhaseDetection:
+			if incident.Detected.IsZero() {
+				incident.Detected = event.Timestamp
+				incident.DetectedByMarker = true
+			}
+		case PhaseMitigation:
+			if incident.Mitigated.IsZero() {
+				incident.Mitigated = event.Timestamp
+			}
+		case PhaseResolution:
+			incident.Resolved = event.Timestamp
+			incident.ResolvedByMarker = true
+		}
+	}
+
+	if incident.Detected.IsZero() {
+		incident.Detected = session.StartTime
+	}
+	if incident.Resolved.IsZero() {
+		incident.Resolved = session.EndTime
+	}
+
+	if !incident.Mitigated.IsZero() && incident.Mitigated.After(incident.Detected) {
+		incident.TimeToMitigation = incident.Mitigated.Sub(incident.Detected)
+	}
+	if incident.Resolved.IsZero() || !incident.Resolved.After(incident.Detected) {
+		return incident
+	}
+
+	incident.TimeToResolution = incident.Resolved.Sub(incident.Detected)
+	incident.ActiveResolution = incident.TimeToResolution
+	for _, gap := range a.FindIdleGaps(session.Events) {
+		start, end := gap.StartTime, gap.EndTime
+		if start.Before(incident.Detected) {
+			start = incident.Detected
+		}
+		if end.After(incident.Resolved) {
+			end = incident.Resolved
+		}
+		if end.After(start) {
+			incident.ActiveResolution -= end.Sub(start)
+		}
+	}
+	return incident
+}
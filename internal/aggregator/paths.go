@@ -0,0 +1,19 @@
+package aggregator
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// RelativePath returns file relative to projectPath when it lies inside the project,
+// and file unchanged otherwise (or when either path is not usable).
+func RelativePath(projectPath, file string) string {
+	if projectPath == "" || !filepath.IsAbs(file) {
+		return file
+	}
+	rel, err := filepath.Rel(projectPath, file)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return file
+	}
+	return rel
+}
@@ -0,0 +1,48 @@
+package aggregator
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+// ExtractTags returns the unique #hashtags found in annotation notes, lowercased and sorted.
+// For example, the note "#bug reproduced with #flaky test" yields ["bug", "flaky"].
+func ExtractTags(events []models.Event) []string {
+	seen := make(map[string]bool)
+	var tags []string
+
+	for _, event := range events {
+		if event.Type != "annotation" {
+			continue
+		}
+		for _, tag := range ParseTags(event.Data.Note) {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	sort.Strings(tags)
+	return tags
+}
+
+// ParseTags returns the #hashtags in a single note in order of appearance.
+func ParseTags(note string) []string {
+	var tags []string
+	for _, word := range strings.Fields(note) {
+		if !strings.HasPrefix(word, "#") {
+			continue
+		}
+		tag := strings.TrimFunc(word[1:], func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_'
+		})
+		if tag != "" {
+			tags = append(tags, strings.ToLower(tag))
+		}
+	}
+	return tags
+}
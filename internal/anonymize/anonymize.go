@@ -0,0 +1,460 @@
+// Package anonymize produces shareable copies of sessions. Project paths, usernames in
+// home directories, file and directory names (extensions kept), hostnames and the session
+// ID are replaced by keyed pseudonyms, and timestamps are shifted to a relative epoch so
+// durations survive but wall-clock times do not. Pseudonyms are derived from a key kept
+// in the data directory, so the same file gets the same pseudonym in every export, and a
+// mapping file written next to the key allows de-anonymization on this machine only.
+package anonymize
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/aggregator"
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+// Export options that switch on anonymization for every output format of a session.
+const (
+	OptionAnonymize = "anonymize"           // "true" exports pseudonymized copies only
+	OptionDropText  = "anonymize.drop_text" // "true" also removes the text of edited lines
+	OptionDropArgs  = "anonymize.drop_args" // "true" keeps only the program name of commands
+)
+
+// DirName is the subdirectory of the data directory holding the key and mapping files.
+const DirName = "anonymize"
+
+// Epoch is the instant a session's start is shifted to. Times in anonymized exports
+// read as offsets from the start, e.g. 00:05:23.
+var Epoch = time.Unix(0, 0).UTC()
+
+// Options controls what is removed in addition to pseudonymization.
+type Options struct {
+	DropLineText bool
+	DropArgs     bool
+}
+
+// Enabled reports whether a session's export options ask for anonymized exports.
+func Enabled(options map[string]string) bool {
+	return isTrue(options[OptionAnonymize])
+}
+
+// OptionsFrom reads Options from a session's export options.
+func OptionsFrom(options map[string]string) Options {
+	return Options{DropLineText: isTrue(options[OptionDropText]), DropArgs: isTrue(options[OptionDropArgs])}
+}
+
+func isTrue(value string) bool {
+	switch strings.ToLower(value) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
+// Mapping records the pseudonyms used for one anonymized session. It never leaves the
+// machine: it is written to the data directory, not next to the exports.
+type Mapping struct {
+	SessionID string            `json:"session_id"` // Original ID
+	Pseudonym string            `json:"pseudonym"`  // ID used in the anonymized exports
+	StartTime time.Time         `json:"start_time"` // Original start, which Epoch stands for
+	Values    map[string]string `json:"values"`     // Pseudonym -> original
+}
+
+// Reveal replaces the pseudonyms in text with the original values, longest first.
+// Relative timestamps are left alone; add their offset to StartTime to recover them.
+func (m *Mapping) Reveal(text string) string {
+	pseudonyms := make([]string, 0, len(m.Values))
+	for pseudonym := range m.Values {
+		pseudonyms = append(pseudonyms, pseudonym)
+	}
+	sort.Slice(pseudonyms, func(i, j int) bool { return len(pseudonyms[i]) > len(pseudonyms[j]) })
+
+	pairs := make([]string, 0, 2*len(pseudonyms))
+	for _, pseudonym := range pseudonyms {
+		pairs = append(pairs, pseudonym, m.Values[pseudonym])
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// Anonymizer pseudonymizes sessions with a fixed key.
+type Anonymizer struct {
+	key      []byte
+	opts     Options
+	hostname string
+	keep     []string // Non-file buffer names left readable, e.g. NvimTree
+}
+
+// New creates an anonymizer. The local hostname is pseudonymized wherever it appears.
+func New(key []byte, opts Options) *Anonymizer {
+	hostname, _ := os.Hostname()
+	return &Anonymizer{key: key, opts: opts, hostname: hostname, keep: aggregator.DefaultConfig().DistractionFiles}
+}
+
+// LoadKey reads the pseudonym key from dir, creating a random one on first use.
+func LoadKey(dir string) ([]byte, error) {
+	keyPath := filepath.Join(dir, "key")
+	if data, err := os.ReadFile(keyPath); err == nil {
+		key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) == 0 {
+			return nil, fmt.Errorf("invalid anonymization key in %s", keyPath)
+		}
+		return key, nil
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(keyPath, []byte(hex.EncodeToString(key)+"\n"), 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// SaveMapping writes a mapping to dir as {pseudonym}.json.
+func SaveMapping(dir string, m *Mapping) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, m.Pseudonym+".json"), data, 0600)
+}
+
+// LoadMapping reads the mapping of an anonymized session ID from dir.
+func LoadMapping(dir, pseudonym string) (*Mapping, error) {
+	data, err := os.ReadFile(filepath.Join(dir, filepath.Base(pseudonym)+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("no mapping for %s: %w", pseudonym, err)
+	}
+	var m Mapping
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Prepare anonymizes a session with the export options' settings, using the key in
+// dataDir/anonymize and saving the mapping there.
+func Prepare(session *models.Session, dataDir string) (*models.Session, error) {
+	dir := filepath.Join(dataDir, DirName)
+	key, err := LoadKey(dir)
+	if err != nil {
+		return nil, err
+	}
+	anon, mapping := New(key, OptionsFrom(session.ExportOptions)).Session(session)
+	if err := SaveMapping(dir, mapping); err != nil {
+		return nil, err
+	}
+	return anon, nil
+}
+
+// Session returns an anonymized copy of a session and the mapping that reverses it.
+// Export options whose values are absolute paths, Unix or Windows, are dropped; the
+// exporters are given the template directory directly (see exporter.Run).
+func (a *Anonymizer) Session(session *models.Session) (*models.Session, *Mapping) {
+	run := &run{Anonymizer: a, session: session, values: make(map[string]string), text: make(map[string]string)}
+	run.collect()
+
+	anon := *session
+	anon.ID = run.pseudonym("session", session.ID)
+	anon.ProjectPath = run.path(session.ProjectPath)
+	anon.SavePath = ""
+	anon.StartTime = run.shift(session.StartTime)
+	anon.EndTime = run.shift(session.EndTime)
+
+	anon.ExportOptions = nil
+	for key, value := range session.ExportOptions {
+		if key == OptionAnonymize || strings.HasPrefix(key, OptionAnonymize+".") {
+			continue
+		}
+		if isAbs(value) {
+			continue
+		}
+		if anon.ExportOptions == nil {
+			anon.ExportOptions = make(map[string]string)
+		}
+		anon.ExportOptions[key] = value
+	}
+
+	anon.Events = make([]models.Event, len(session.Events))
+	for i, event := range session.Events {
+		event.Timestamp = run.shift(event.Timestamp)
+		if event.Source != "" {
+			event.Source = run.pseudonym("session", event.Source)
+		}
+		data := &event.Data
+		if data.Filename != "" {
+			data.Filename = run.path(data.Filename)
+		}
+		if a.opts.DropLineText {
+			data.LineText = ""
+		} else {
+			data.LineText = run.replace(data.LineText)
+		}
+		if a.opts.DropArgs {
+			data.Command = run.program(data.Command)
+		} else {
+			data.Command = run.replace(data.Command)
+		}
+		data.Message = run.replace(data.Message)
+		data.Note = run.replace(data.Note)
+		anon.Events[i] = event
+	}
+
+	return &anon, &Mapping{
+		SessionID: session.ID,
+		Pseudonym: anon.ID,
+		StartTime: session.StartTime,
+		Values:    run.values,
+	}
+}
+
+// run holds the state of anonymizing one session.
+type run struct {
+	*Anonymizer
+	session   *models.Session
+	values    map[string]string // Pseudonym -> original, for the mapping
+	text      map[string]string // Original -> pseudonym, replaced inside free text
+	replacer  *strings.Replacer
+	localHost *regexp.Regexp
+}
+
+// hostPattern finds hostnames in URLs, user@host and scp-style host: arguments.
+var hostPattern = regexp.MustCompile(`(://|\w@)([A-Za-z0-9][A-Za-z0-9.-]*[A-Za-z0-9])`)
+
+// collect registers every path, user and hostname so free text can be rewritten consistently.
+func (r *run) collect() {
+	r.text[r.session.ID] = r.pseudonym("session", r.session.ID)
+	if r.session.ProjectPath != "" {
+		r.text[r.session.ProjectPath] = r.path(r.session.ProjectPath)
+	}
+	for _, event := range r.session.Events {
+		name := event.Data.Filename
+		if name == "" || r.kept(name) {
+			continue
+		}
+		anon := r.path(name)
+		r.text[name] = anon
+		// Bare names are only rewritten with an extension, so "go" or "src" in a note stay readable
+		dir, base := splitName(name)
+		if strings.LastIndex(base, ".") > 0 {
+			r.text[base] = path.Base(anon)
+		}
+		if dir != "" && dir != "/" && !strings.HasSuffix(dir, ":") {
+			r.text[dir] = path.Dir(anon)
+		}
+	}
+	if r.hostname != "" && r.hostname != "localhost" {
+		// Short hostnames such as "vm" must not match inside words like "jvm"
+		r.localHost = regexp.MustCompile(`\b` + regexp.QuoteMeta(r.hostname) + `\b`)
+	}
+
+	pairs := make([]string, 0, 2*len(r.text))
+	originals := make([]string, 0, len(r.text))
+	for original := range r.text {
+		originals = append(originals, original)
+	}
+	sort.Slice(originals, func(i, j int) bool { return len(originals[i]) > len(originals[j]) })
+	for _, original := range originals {
+		pairs = append(pairs, original, r.text[original])
+	}
+	r.replacer = strings.NewReplacer(pairs...)
+}
+
+// replace rewrites known paths, users and hosts in free text.
+func (r *run) replace(text string) string {
+	if text == "" {
+		return ""
+	}
+	text = r.users(r.replacer.Replace(text))
+	if r.localHost != nil {
+		text = r.localHost.ReplaceAllLiteralString(text, r.pseudonym("host", r.hostname))
+	}
+	return hostPattern.ReplaceAllStringFunc(text, func(match string) string {
+		groups := hostPattern.FindStringSubmatch(match)
+		host := groups[2]
+		if host == "localhost" || strings.HasPrefix(host, "host-") || isAddress(host, "127.") {
+			return match
+		}
+		return groups[1] + r.pseudonym("host", host)
+	})
+}
+
+// userPattern finds the user directory of Unix, macOS and Windows home paths.
+var userPattern = regexp.MustCompile(`(/home/|/Users/|[A-Za-z]:[\\/]Users[\\/])([^/\\\s"']+)`)
+
+// users pseudonymizes usernames in home paths that were not part of a recorded file name.
+func (r *run) users(text string) string {
+	return userPattern.ReplaceAllStringFunc(text, func(match string) string {
+		groups := userPattern.FindStringSubmatch(match)
+		if strings.HasPrefix(groups[2], "user-") {
+			return match
+		}
+		return groups[1] + r.pseudonym("user", groups[2])
+	})
+}
+
+// program keeps only the program name of a command line, pseudonymized if it is a path.
+func (r *run) program(command string) string {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return ""
+	}
+	if strings.ContainsAny(fields[0], `/\`) {
+		return r.path(fields[0])
+	}
+	return fields[0]
+}
+
+// path pseudonymizes a file path. Paths inside the project keep their layout below the
+// project's pseudonym; elsewhere, home directories keep their /home or /Users prefix with
+// the username replaced, and Windows paths keep their drive letter. Every other component
+// is replaced, keeping file extensions.
+func (r *run) path(name string) string {
+	if r.kept(name) {
+		return name
+	}
+	name = slashes(name)
+	project := slashes(r.session.ProjectPath)
+
+	var prefix string
+	var rest []string
+	switch {
+	case project != "" && name == project:
+		return "/" + r.pseudonym("project", project)
+	case project != "" && strings.HasPrefix(name, project+"/"):
+		prefix = "/" + r.pseudonym("project", project)
+		rest = strings.Split(strings.TrimPrefix(name, project+"/"), "/")
+	case !strings.HasPrefix(name, "/") && volume(name) == "":
+		// Relative names are relative to the project
+		if project != "" {
+			prefix = "/" + r.pseudonym("project", project)
+		}
+		rest = strings.Split(name, "/")
+		if project == "" {
+			return strings.Join(r.components(rest), "/")
+		}
+	default:
+		prefix = volume(name)
+		parts := strings.Split(strings.TrimPrefix(name[len(prefix):], "/"), "/")
+		if len(parts) > 1 && (parts[0] == "home" || parts[0] == "Users") {
+			prefix += "/" + parts[0] + "/" + r.pseudonym("user", parts[1])
+			parts = parts[2:]
+		}
+		rest = parts
+	}
+
+	if len(rest) == 0 {
+		return prefix
+	}
+	return prefix + "/" + strings.Join(r.components(rest), "/")
+}
+
+// components pseudonymizes path components, the last one as a file keeping its extension.
+func (r *run) components(parts []string) []string {
+	out := make([]string, len(parts))
+	for i, part := range parts {
+		switch {
+		case part == "" || part == "." || part == "..":
+			out[i] = part
+		case i == len(parts)-1:
+			ext := path.Ext(part)
+			if ext == part {
+				ext = "" // dotfiles such as .env
+			}
+			out[i] = r.pseudonym("file", strings.TrimSuffix(part, ext)) + ext
+		default:
+			out[i] = r.pseudonym("dir", part)
+		}
+	}
+	return out
+}
+
+// kept reports whether a buffer name is a plugin buffer such as NvimTree, which carries
+// no identity and is needed to measure distraction time.
+func (r *run) kept(name string) bool {
+	if strings.ContainsAny(name, `/\`) {
+		return false
+	}
+	for _, pattern := range r.keep {
+		if strings.Contains(name, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// pseudonym derives a stable pseudonym such as file-3f2a9c1d and records it in the mapping.
+func (r *run) pseudonym(kind, value string) string {
+	if kind == "project" {
+		// Anonymized project paths are "/" + pseudonym, so Reveal restores the leading slash
+		value = strings.TrimPrefix(value, "/")
+	}
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(kind + ":" + value))
+	pseudonym := kind + "-" + hex.EncodeToString(mac.Sum(nil))[:8]
+	r.values[pseudonym] = value
+	return pseudonym
+}
+
+// shift moves a timestamp to the same offset from Epoch as it had from the session start.
+func (r *run) shift(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return Epoch.Add(t.Sub(r.session.StartTime))
+}
+
+// slashes writes a Unix or Windows path with forward slashes, on any platform.
+func slashes(name string) string {
+	return strings.ReplaceAll(name, `\`, "/")
+}
+
+// volume returns the drive of a Windows path such as C:\Users, or "".
+func volume(name string) string {
+	if len(name) >= 2 && name[1] == ':' && ('a' <= name[0]|0x20 && name[0]|0x20 <= 'z') &&
+		(len(name) == 2 || name[2] == '/' || name[2] == '\\') {
+		return name[:2]
+	}
+	return ""
+}
+
+// isAbs reports whether a value is an absolute Unix or Windows path, on any platform.
+func isAbs(value string) bool {
+	return filepath.IsAbs(value) || strings.HasPrefix(slashes(value), "/") || volume(value) != ""
+}
+
+// splitName splits a Unix or Windows file name at its last separator. The directory is
+// empty for a bare name.
+func splitName(name string) (string, string) {
+	i := strings.LastIndexAny(name, `/\`)
+	if i < 0 {
+		return "", name
+	}
+	if i == 0 {
+		return "/", name[1:]
+	}
+	return name[:i], name[i+1:]
+}
+
+func isAddress(host, prefix string) bool {
+	return strings.HasPrefix(host, prefix) && strings.Trim(host, "0123456789.") == ""
+}
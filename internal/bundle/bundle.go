@@ -0,0 +1,416 @@
+// Package bundle packs a session into a single portable .capytrace.tar.zst file and
+// validates and installs such files. A bundle holds the raw events, a metadata summary,
+// the generated reports and, optionally, snapshots of the files the session touched,
+// listed with their SHA-256 checksums in manifest.json.
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/andev0x/capytrace.nvim/internal/aggregator"
+	"github.com/andev0x/capytrace.nvim/internal/models"
+	"github.com/andev0x/capytrace.nvim/internal/store"
+)
+
+// Extension is the file name suffix of session bundles.
+const Extension = ".capytrace.tar.zst"
+
+// Format identifies capytrace bundles in the manifest.
+const Format = "capytrace-bundle"
+
+// SchemaVersion is the bundle layout written by Create. Open accepts this version and older ones.
+const SchemaVersion = 1
+
+// ManifestName is the manifest's path inside the archive; it is always the first entry.
+const ManifestName = "manifest.json"
+
+// DefaultMaxSnapshotSize skips snapshots of files larger than 1 MiB.
+const DefaultMaxSnapshotSize = 1 << 20
+
+// MaxUncompressedSize is the most Open decompresses from a bundle, manifest included;
+// sizes listed in the manifest are not trusted beyond it.
+const MaxUncompressedSize = 512 << 20
+
+// File roles in the manifest.
+const (
+	RoleRaw      = "raw"
+	RoleMetadata = "metadata"
+	RoleReport   = "report"
+	RoleSnapshot = "snapshot"
+)
+
+// Manifest describes a bundle's contents.
+type Manifest struct {
+	Format        string    `json:"format"`
+	SchemaVersion int       `json:"schema_version"`
+	SessionID     string    `json:"session_id"`
+	CreatedAt     time.Time `json:"created_at"`
+	Files         []File    `json:"files"`
+}
+
+// File is one manifest entry. Paths are slash-separated and relative to the archive root.
+type File struct {
+	Path   string `json:"path"`
+	Role   string `json:"role"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Metadata summarizes the session so a bundle can be inspected without parsing the events.
+type Metadata struct {
+	ID           string    `json:"id"`
+	ProjectPath  string    `json:"project_path"`
+	StartTime    time.Time `json:"start_time"`
+	EndTime      time.Time `json:"end_time"`
+	Active       bool      `json:"active"`
+	OutputFormat string    `json:"output_format"`
+	EventCount   int       `json:"event_count"`
+	Tags         []string  `json:"tags"`
+	Files        []string  `json:"files"` // Files the session touched, as recorded
+}
+
+// Options controls what Create adds to a bundle.
+type Options struct {
+	Snapshots       bool  // Include the current contents of the files the session touched
+	MaxSnapshotSize int64 // Larger files are skipped; 0 means DefaultMaxSnapshotSize
+}
+
+// Bundle is a validated bundle read by Open.
+type Bundle struct {
+	Manifest  Manifest
+	Metadata  Metadata
+	Session   *models.Session
+	Reports   map[string][]byte // Keyed by file name, e.g. {id}.md
+	Snapshots map[string][]byte // Keyed by slash-separated path relative to the project
+}
+
+// entry is a file waiting to be written into the archive.
+type entry struct {
+	path string
+	role string
+	data []byte
+}
+
+// Create writes the stored session as a bundle to w and returns its manifest.
+func Create(w io.Writer, savePath, sessionID string, opts Options) (*Manifest, error) {
+	session, err := store.Read(savePath, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []entry
+	hasRaw := false
+	for _, file := range store.Artifacts(savePath, sessionID) {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		name := filepath.Base(file)
+		switch name {
+		case sessionID + "_raw.json", sessionID + ".json":
+			if hasRaw {
+				continue // legacy {id}.json next to {id}_raw.json
+			}
+			hasRaw = true
+			entries = append(entries, entry{path: "raw/" + sessionID + "_raw.json", role: RoleRaw, data: data})
+		case store.SummaryFile:
+			// Describes the save path rather than the session; Install regenerates exports instead
+		default:
+			entries = append(entries, entry{path: "reports/" + name, role: RoleReport, data: data})
+		}
+	}
+
+	metadata := describe(session)
+	encoded, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, entry{path: "metadata.json", role: RoleMetadata, data: encoded})
+
+	if opts.Snapshots {
+		limit := opts.MaxSnapshotSize
+		if limit <= 0 {
+			limit = DefaultMaxSnapshotSize
+		}
+		entries = append(entries, snapshots(session, metadata.Files, limit)...)
+	}
+
+	manifest := &Manifest{
+		Format:        Format,
+		SchemaVersion: SchemaVersion,
+		SessionID:     sessionID,
+		CreatedAt:     time.Now().UTC(),
+	}
+	for _, e := range entries {
+		sum := sha256.Sum256(e.data)
+		manifest.Files = append(manifest.Files, File{Path: e.path, Role: e.role, Size: int64(len(e.data)), SHA256: hex.EncodeToString(sum[:])})
+	}
+	encoded, err = json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	zw, err := zstd.NewWriter(w)
+	if err != nil {
+		return nil, err
+	}
+	tw := tar.NewWriter(zw)
+	entries = append([]entry{{path: ManifestName, data: encoded}}, entries...)
+	for _, e := range entries {
+		header := &tar.Header{Name: e.path, Mode: 0644, Size: int64(len(e.data)), ModTime: manifest.CreatedAt, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(e.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// describe builds the metadata summary of a session.
+func describe(session *models.Session) Metadata {
+	metadata := Metadata{
+		ID:           session.ID,
+		ProjectPath:  session.ProjectPath,
+		StartTime:    session.StartTime,
+		EndTime:      session.EndTime,
+		Active:       session.Active,
+		OutputFormat: session.OutputFormat,
+		EventCount:   len(session.Events),
+		Tags:         aggregator.ExtractTags(session.Events),
+		Files:        []string{},
+	}
+
+	seen := make(map[string]bool)
+	for _, event := range session.Events {
+		if name := event.Data.Filename; name != "" && !seen[name] {
+			seen[name] = true
+			metadata.Files = append(metadata.Files, name)
+		}
+	}
+	sort.Strings(metadata.Files)
+	return metadata
+}
+
+// snapshots reads the touched files that lie inside the project directory. Missing,
+// oversized and outside files are skipped, since a snapshot is a best-effort aid.
+func snapshots(session *models.Session, files []string, limit int64) []entry {
+	var entries []entry
+	seen := make(map[string]bool)
+	for _, name := range files {
+		file := name
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(session.ProjectPath, file)
+		}
+		rel, err := filepath.Rel(session.ProjectPath, file)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") || seen[rel] {
+			continue
+		}
+		seen[rel] = true
+		stat, err := os.Stat(file)
+		if err != nil || !stat.Mode().IsRegular() || stat.Size() > limit {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entry{path: "snapshots/" + filepath.ToSlash(rel), role: RoleSnapshot, data: data})
+	}
+	return entries
+}
+
+// Open reads and validates a bundle: the manifest must come first with a known format
+// and supported schema version, every file must match its size and checksum, no file may
+// be missing or unlisted, the raw events must belong to the manifest's session, and the
+// session ID must be usable as a file name. At most MaxUncompressedSize bytes are read.
+func Open(r io.Reader) (*Bundle, error) {
+	zr, err := zstd.NewReader(r, zstd.WithDecoderMaxMemory(MaxUncompressedSize))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	tr := tar.NewReader(zr)
+	budget := int64(MaxUncompressedSize)
+
+	header, err := tr.Next()
+	if err != nil || header.Name != ManifestName {
+		return nil, errors.New("not a capytrace bundle: manifest.json missing")
+	}
+	if header.Size > budget {
+		return nil, fmt.Errorf("bundle exceeds %d MiB uncompressed", MaxUncompressedSize>>20)
+	}
+	budget -= header.Size
+	var manifest Manifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if manifest.Format != Format {
+		return nil, fmt.Errorf("not a capytrace bundle: format %q", manifest.Format)
+	}
+	if manifest.SchemaVersion < 1 || manifest.SchemaVersion > SchemaVersion {
+		return nil, fmt.Errorf("unsupported bundle schema version %d (this build reads up to %d)", manifest.SchemaVersion, SchemaVersion)
+	}
+	if err := store.ValidateID(manifest.SessionID); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+
+	listed := make(map[string]File)
+	for _, file := range manifest.Files {
+		if file.Size < 0 || file.Size > budget {
+			return nil, fmt.Errorf("invalid size of %s in manifest: %d", file.Path, file.Size)
+		}
+		if role := roleOf(file.Path); role == "" || role != file.Role {
+			return nil, fmt.Errorf("invalid path in manifest: %q", file.Path)
+		}
+		if file.Role == RoleReport && !strings.HasPrefix(path.Base(file.Path), manifest.SessionID) {
+			return nil, fmt.Errorf("report %s does not belong to session %s", file.Path, manifest.SessionID)
+		}
+		listed[file.Path] = file
+	}
+
+	b := &Bundle{Manifest: manifest, Reports: make(map[string][]byte), Snapshots: make(map[string][]byte)}
+	seen := make(map[string]bool)
+	var raw, metadata []byte
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		file, ok := listed[header.Name]
+		if !ok || seen[header.Name] {
+			return nil, fmt.Errorf("unexpected file in bundle: %s", header.Name)
+		}
+		seen[header.Name] = true
+		if file.Size > budget {
+			return nil, fmt.Errorf("bundle exceeds %d MiB uncompressed", MaxUncompressedSize>>20)
+		}
+
+		var buf bytes.Buffer
+		hash := sha256.New()
+		n, err := io.Copy(io.MultiWriter(&buf, hash), io.LimitReader(tr, file.Size+1))
+		if err != nil {
+			return nil, err
+		}
+		budget -= n
+		if n != file.Size || hex.EncodeToString(hash.Sum(nil)) != file.SHA256 {
+			return nil, fmt.Errorf("checksum mismatch: %s", header.Name)
+		}
+
+		switch file.Role {
+		case RoleRaw:
+			raw = buf.Bytes()
+		case RoleMetadata:
+			metadata = buf.Bytes()
+		case RoleReport:
+			b.Reports[path.Base(file.Path)] = buf.Bytes()
+		case RoleSnapshot:
+			b.Snapshots[strings.TrimPrefix(file.Path, "snapshots/")] = buf.Bytes()
+		}
+	}
+
+	for _, file := range manifest.Files {
+		if !seen[file.Path] {
+			return nil, fmt.Errorf("file missing from bundle: %s", file.Path)
+		}
+	}
+	if raw == nil {
+		return nil, errors.New("bundle has no raw events")
+	}
+
+	var session models.Session
+	if err := json.Unmarshal(raw, &session); err != nil {
+		return nil, fmt.Errorf("invalid raw events: %w", err)
+	}
+	if err := store.ValidateID(session.ID); err != nil {
+		return nil, fmt.Errorf("invalid raw events: %w", err)
+	}
+	if session.ID != manifest.SessionID {
+		return nil, fmt.Errorf("raw events belong to session %s, manifest names %s", session.ID, manifest.SessionID)
+	}
+	b.Session = &session
+
+	if metadata != nil {
+		if err := json.Unmarshal(metadata, &b.Metadata); err != nil {
+			return nil, fmt.Errorf("invalid metadata: %w", err)
+		}
+	}
+	return b, nil
+}
+
+// OpenFile opens and validates the bundle at path.
+func OpenFile(name string) (*Bundle, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return Open(f)
+}
+
+// Install stores the session and its reports in savePath and SQLite, renaming it when
+// its ID is taken (see store.Import). It returns the installed session ID.
+func (b *Bundle) Install(savePath string) (string, error) {
+	return store.Import(savePath, b.Session, b.Reports)
+}
+
+// ExtractSnapshots writes the file snapshots below dir, keeping their project-relative paths.
+func (b *Bundle) ExtractSnapshots(dir string) error {
+	for rel, data := range b.Snapshots {
+		dest := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(dest, data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// roleOf returns the role implied by a path's directory, or "" when the path is not a
+// clean, relative, slash-separated path in one of the bundle's directories. This keeps
+// extraction from escaping its target.
+func roleOf(p string) string {
+	if p == "" || path.IsAbs(p) || path.Clean(p) != p || strings.HasPrefix(p, "../") || strings.Contains(p, "\\") {
+		return ""
+	}
+	switch dir, name := path.Split(p); {
+	case dir == "" && name == "metadata.json":
+		return RoleMetadata
+	case dir == "raw/" && name != "":
+		return RoleRaw
+	case dir == "reports/" && name != "":
+		return RoleReport
+	case strings.HasPrefix(dir, "snapshots/"):
+		return RoleSnapshot
+	default:
+		return ""
+	}
+}
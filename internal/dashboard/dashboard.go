@@ -0,0 +1,342 @@
+// Package dashboard serves a read-only web UI and JSON REST API over recorded sessions.
+// Sessions are read from the save path and the shared SQLite database; the UI is embedded
+// in the binary and works offline.
+package dashboard
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/aggregator"
+	"github.com/andev0x/capytrace.nvim/internal/exporter"
+	"github.com/andev0x/capytrace.nvim/internal/models"
+	"github.com/andev0x/capytrace.nvim/internal/report"
+	"github.com/andev0x/capytrace.nvim/internal/store"
+	"github.com/andev0x/capytrace.nvim/internal/stream"
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+// Server answers dashboard requests for one save path.
+type Server struct {
+	savePath string
+	database *exporter.SQLiteExporter
+	config   *aggregator.AggregatorConfig
+}
+
+// New creates a dashboard over the sessions in savePath and the database in dataDir.
+func New(savePath, dataDir string) *Server {
+	return &Server{
+		savePath: savePath,
+		database: exporter.NewSQLiteExporter(dataDir),
+		config:   aggregator.DefaultConfig(),
+	}
+}
+
+// SessionSummary is the metadata returned by the session list endpoint.
+type SessionSummary struct {
+	ID          string        `json:"id"`
+	ProjectPath string        `json:"project_path"`
+	StartTime   time.Time     `json:"start_time"`
+	EndTime     time.Time     `json:"end_time"`
+	Active      bool          `json:"active"`
+	Duration    time.Duration `json:"duration"`
+	Events      int           `json:"events"`
+	FileEdits   int           `json:"file_edits"`
+	Commands    int           `json:"commands"`
+	Annotations int           `json:"annotations"`
+	Tags        []string      `json:"tags"`
+	Origin      string        `json:"origin"` // "file" or "database"
+}
+
+// Handler returns the HTTP handler serving the API under /api/ and the UI at /.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/sessions", s.handleSessions)
+	mux.HandleFunc("GET /api/sessions/{id}", s.handleSession)
+	mux.HandleFunc("GET /api/sessions/{id}/analytics", s.handleAnalytics)
+	mux.HandleFunc("GET /api/sessions/{id}/timeline", s.handleTimeline)
+	mux.HandleFunc("GET /api/trends", s.handleTrends)
+	mux.HandleFunc("GET /api/search", s.handleSearch)
+
+	static, _ := fs.Sub(staticFiles, "static")
+	mux.Handle("GET /", http.FileServer(http.FS(static)))
+
+	return localOnly(mux)
+}
+
+// localOnly rejects requests whose Host header is not a loopback name, so a web page
+// cannot reach the dashboard through DNS rebinding.
+func localOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !stream.IsLoopbackHost(r.Host) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("host %q not allowed", r.Host))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleSessions lists sessions, newest first.
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
+	q, err := parseQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	sessions, origins, err := s.load(q)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	summaries := make([]SessionSummary, 0, len(sessions))
+	for i := len(sessions) - 1; i >= 0; i-- {
+		summaries = append(summaries, summarize(sessions[i], origins[sessions[i].ID]))
+	}
+	writeJSON(w, summaries)
+}
+
+// handleSession returns a session with all of its raw events.
+func (s *Server) handleSession(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.find(w, r.PathValue("id"))
+	if !ok {
+		return
+	}
+	writeJSON(w, session)
+}
+
+// handleAnalytics returns the activity blocks and analytics computed by the aggregator.
+func (s *Server) handleAnalytics(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.find(w, r.PathValue("id"))
+	if !ok {
+		return
+	}
+
+	blocks, analytics := aggregator.New(s.config).AggregateSession(session)
+	if blocks == nil {
+		blocks = []models.ActivityBlock{}
+	}
+	writeJSON(w, struct {
+		Blocks    []models.ActivityBlock   `json:"blocks"`
+		Analytics *models.SessionAnalytics `json:"analytics"`
+		FlowTime  time.Duration            `json:"flow_time"`
+	}{blocks, analytics, report.FlowTime(analytics)})
+}
+
+// handleTimeline returns the grouped timeline shown in Markdown reports.
+func (s *Server) handleTimeline(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.find(w, r.PathValue("id"))
+	if !ok {
+		return
+	}
+
+	timeline := exporter.GroupTimeline(session.Events)
+	if timeline == nil {
+		timeline = []exporter.TimelineEvent{}
+	}
+	writeJSON(w, timeline)
+}
+
+// handleTrends returns a range report grouped by the group_by parameter (day by default).
+func (s *Server) handleTrends(w http.ResponseWriter, r *http.Request) {
+	q, err := parseQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	sessions, _, err := s.load(q)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	rep, err := report.Build(sessions, r.URL.Query().Get("group_by"), s.config)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	rep.Project = q.Project
+	if !q.Since.IsZero() {
+		rep.Since = &q.Since
+	}
+	if !q.Until.IsZero() {
+		rep.Until = &q.Until
+	}
+	writeJSON(w, rep)
+}
+
+// handleSearch returns events whose text matches the q parameter.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	term := r.URL.Query().Get("q")
+	if term == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("missing q parameter"))
+		return
+	}
+
+	limit := defaultSearchLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit %q", value))
+			return
+		}
+		limit = n
+	}
+
+	q, err := parseQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	sessions, _, err := s.load(q)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, Search(sessions, term, limit))
+}
+
+// load returns the sessions matching q from the save path and the database, oldest first.
+// Sessions present in both come from the save path. The map records where each session was read from.
+func (s *Server) load(q store.Query) ([]*models.Session, map[string]string, error) {
+	sessions, err := store.LoadRange(s.savePath, q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	origins := make(map[string]string, len(sessions))
+	for _, session := range sessions {
+		origins[session.ID] = "file"
+	}
+
+	stored, err := s.database.LoadSessions()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read database: %w", err)
+	}
+	for _, session := range stored {
+		if _, ok := origins[session.ID]; ok || !q.Match(session) {
+			continue
+		}
+		origins[session.ID] = "database"
+		sessions = append(sessions, session)
+	}
+
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].StartTime.Before(sessions[j].StartTime)
+	})
+	return sessions, origins, nil
+}
+
+// find loads one session by ID, writing a 404 response when it does not exist.
+func (s *Server) find(w http.ResponseWriter, id string) (*models.Session, bool) {
+	if err := store.ValidateID(id); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+	if session, err := store.Read(s.savePath, id); err == nil {
+		return session, true
+	}
+
+	stored, err := s.database.LoadSession(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return nil, false
+	}
+	if stored != nil {
+		return stored, true
+	}
+
+	writeError(w, http.StatusNotFound, fmt.Errorf("session %s not found", id))
+	return nil, false
+}
+
+// summarize counts the events of a session for the session list.
+func summarize(session *models.Session, origin string) SessionSummary {
+	summary := SessionSummary{
+		ID:          session.ID,
+		ProjectPath: session.ProjectPath,
+		StartTime:   session.StartTime,
+		EndTime:     session.EndTime,
+		Active:      session.Active,
+		Duration:    report.SessionDuration(session),
+		Events:      len(session.Events),
+		Tags:        aggregator.ExtractTags(session.Events),
+		Origin:      origin,
+	}
+	if summary.Tags == nil {
+		summary.Tags = []string{}
+	}
+
+	for _, event := range session.Events {
+		switch event.Type {
+		case "file_edit":
+			summary.FileEdits++
+		case "terminal_command":
+			summary.Commands++
+		case "annotation":
+			summary.Annotations++
+		}
+	}
+	return summary
+}
+
+// parseQuery reads the since, until and project parameters.
+// Dates are YYYY-MM-DD in local time or RFC 3339; a plain until date includes that whole day.
+func parseQuery(r *http.Request) (store.Query, error) {
+	params := r.URL.Query()
+	q := store.Query{Project: params.Get("project")}
+
+	if value := params.Get("since"); value != "" {
+		t, _, err := parseTime(value)
+		if err != nil {
+			return q, fmt.Errorf("invalid since: %w", err)
+		}
+		q.Since = t
+	}
+	if value := params.Get("until"); value != "" {
+		t, isDate, err := parseTime(value)
+		if err != nil {
+			return q, fmt.Errorf("invalid until: %w", err)
+		}
+		if isDate {
+			t = t.AddDate(0, 0, 1)
+		}
+		q.Until = t
+	}
+	return q, nil
+}
+
+// parseTime parses a local date or an RFC 3339 time, reporting whether it was a plain date.
+func parseTime(value string) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, true, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	return time.Time{}, false, fmt.Errorf("%q is not a date or RFC 3339 time", value)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
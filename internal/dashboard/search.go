@@ -0,0 +1,74 @@
+package dashboard
+
+import (
+	"strings"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+// defaultSearchLimit caps the number of hits returned when no limit is given.
+const defaultSearchLimit = 200
+
+// Hit is a single event matching a search.
+type Hit struct {
+	SessionID   string    `json:"session_id"`
+	ProjectPath string    `json:"project_path"`
+	Index       int       `json:"index"` // Position of the event in the session
+	Type        string    `json:"type"`
+	Timestamp   time.Time `json:"timestamp"`
+	Filename    string    `json:"filename,omitempty"`
+	Line        int       `json:"line,omitempty"`
+	Text        string    `json:"text"` // The field that matched
+}
+
+// Search finds events whose note, command, diagnostic message, line text or
+// filename contains term (case-insensitive). Newer sessions are searched first.
+func Search(sessions []*models.Session, term string, limit int) []Hit {
+	needle := strings.ToLower(term)
+	hits := []Hit{}
+
+	for i := len(sessions) - 1; i >= 0; i-- {
+		session := sessions[i]
+		for index, event := range session.Events {
+			text, ok := matchEvent(event, needle)
+			if !ok {
+				continue
+			}
+			hits = append(hits, Hit{
+				SessionID:   session.ID,
+				ProjectPath: session.ProjectPath,
+				Index:       index,
+				Type:        event.Type,
+				Timestamp:   event.Timestamp,
+				Filename:    event.Data.Filename,
+				Line:        event.Data.Line,
+				Text:        text,
+			})
+			if len(hits) >= limit {
+				return hits
+			}
+		}
+	}
+	return hits
+}
+
+// matchEvent returns the first searchable field of the event containing needle.
+// Cursor movements are skipped since they only repeat file names.
+func matchEvent(event models.Event, needle string) (string, bool) {
+	if event.Type == "cursor_move" {
+		return "", false
+	}
+	for _, field := range []string{
+		event.Data.Note,
+		event.Data.Command,
+		event.Data.Message,
+		event.Data.LineText,
+		event.Data.Filename,
+	} {
+		if field != "" && strings.Contains(strings.ToLower(field), needle) {
+			return field, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,286 @@
+// CapyTrace dashboard: a small hash-routed UI over the JSON API served by `capytrace dashboard`.
+(function () {
+  "use strict";
+
+  const app = document.getElementById("app");
+
+  // --- helpers ---------------------------------------------------------------
+
+  function esc(value) {
+    return String(value == null ? "" : value)
+      .replace(/&/g, "&amp;")
+      .replace(/</g, "&lt;")
+      .replace(/>/g, "&gt;")
+      .replace(/"/g, "&quot;");
+  }
+
+  // Durations arrive as Go time.Duration values (nanoseconds).
+  function duration(ns) {
+    let seconds = Math.round((ns || 0) / 1e9);
+    const h = Math.floor(seconds / 3600);
+    seconds -= h * 3600;
+    const m = Math.floor(seconds / 60);
+    const s = seconds - m * 60;
+    if (h > 0) return h + "h " + m + "m";
+    if (m > 0) return m + "m " + s + "s";
+    return s + "s";
+  }
+
+  function when(value) {
+    if (!value || value.startsWith("0001-")) return "—";
+    return new Date(value).toLocaleString();
+  }
+
+  function clock(value) {
+    return new Date(value).toLocaleTimeString();
+  }
+
+  function base(path) {
+    return String(path || "").split(/[\\/]/).pop();
+  }
+
+  function params(obj) {
+    const p = new URLSearchParams();
+    Object.keys(obj).forEach(function (k) {
+      if (obj[k]) p.set(k, obj[k]);
+    });
+    const s = p.toString();
+    return s ? "?" + s : "";
+  }
+
+  async function api(path) {
+    const res = await fetch("api/" + path);
+    const body = await res.json();
+    if (!res.ok) throw new Error(body.error || res.statusText);
+    return body;
+  }
+
+  function card(label, value) {
+    return '<div class="card"><div class="value">' + esc(value) + '</div><div class="label">' + esc(label) + "</div></div>";
+  }
+
+  function tags(list) {
+    return (list || []).map(function (t) { return '<span class="tag">#' + esc(t) + "</span>"; }).join("");
+  }
+
+  function sessionLink(id) {
+    return '<a href="#/session/' + encodeURIComponent(id) + '">' + esc(id) + "</a>";
+  }
+
+  // --- views -----------------------------------------------------------------
+
+  async function sessionsView(query) {
+    const filters = {
+      since: query.get("since") || "",
+      until: query.get("until") || "",
+      project: query.get("project") || "",
+    };
+    const sessions = await api("sessions" + params(filters));
+
+    let html = '<form class="filters" id="filters">' +
+      '<label>Since <input type="date" name="since" value="' + esc(filters.since) + '"></label>' +
+      '<label>Until <input type="date" name="until" value="' + esc(filters.until) + '"></label>' +
+      '<label>Project <input name="project" value="' + esc(filters.project) + '"></label>' +
+      "<button>Filter</button></form>";
+
+    html += "<h2>Sessions (" + sessions.length + ")</h2>";
+    html += '<table><tr><th>Session</th><th>Project</th><th>Started</th><th class="num">Duration</th>' +
+      '<th class="num">Events</th><th class="num">Edits</th><th class="num">Commands</th><th class="num">Notes</th><th>Tags</th></tr>';
+    sessions.forEach(function (s) {
+      html += "<tr><td>" + sessionLink(s.id) + (s.active ? ' <span class="tag">active</span>' : "") +
+        (s.origin === "database" ? ' <span class="muted">(db)</span>' : "") + "</td>" +
+        '<td title="' + esc(s.project_path) + '">' + esc(base(s.project_path)) + "</td>" +
+        "<td>" + esc(when(s.start_time)) + "</td>" +
+        '<td class="num">' + duration(s.duration) + "</td>" +
+        '<td class="num">' + s.events + "</td>" +
+        '<td class="num">' + s.file_edits + "</td>" +
+        '<td class="num">' + s.commands + "</td>" +
+        '<td class="num">' + s.annotations + "</td>" +
+        "<td>" + tags(s.tags) + "</td></tr>";
+    });
+    html += "</table>";
+    app.innerHTML = html;
+
+    document.getElementById("filters").addEventListener("submit", function (e) {
+      e.preventDefault();
+      const data = new FormData(e.target);
+      location.hash = "#/" + params({ since: data.get("since"), until: data.get("until"), project: data.get("project") });
+    });
+  }
+
+  async function sessionView(id) {
+    const enc = encodeURIComponent(id);
+    const [session, stats, timeline] = await Promise.all([
+      api("sessions/" + enc),
+      api("sessions/" + enc + "/analytics"),
+      api("sessions/" + enc + "/timeline"),
+    ]);
+    const a = stats.analytics;
+
+    let html = "<h2>" + esc(session.id) + "</h2>";
+    html += '<p class="muted"><code>' + esc(session.project_path) + "</code> · " +
+      esc(when(session.start_time)) + " → " + esc(when(session.end_time)) + "</p>";
+
+    html += '<div class="cards">' +
+      card("Events", session.events.length) +
+      card("Activity blocks", stats.blocks.length) +
+      card("Flow time", duration(stats.flow_time)) +
+      card("Idle time", duration(a.total_idle_time)) +
+      card("Focus ratio", Math.round((a.focus_ratio || 0) * 100) + "%") +
+      card("Peak velocity", (a.peak_velocity || 0).toFixed(2)) +
+      "</div>";
+
+    const files = Object.keys(a.main_files || {}).map(function (f) { return [f, a.main_files[f]]; });
+    files.sort(function (x, y) { return y[1] - x[1]; });
+    const total = files.reduce(function (sum, f) { return sum + f[1]; }, 0);
+    html += '<h3>Files</h3><table><tr><th>File</th><th class="num">Time</th><th></th></tr>';
+    files.forEach(function (f) {
+      const pct = total ? (f[1] / total) * 100 : 0;
+      html += '<tr><td title="' + esc(f[0]) + '"><code>' + esc(base(f[0])) + "</code></td>" +
+        '<td class="num">' + duration(f[1] * 1e9) + "</td>" +
+        '<td><span class="bar" style="width:' + Math.round(pct * 2) + 'px"></span></td></tr>';
+    });
+    html += "</table>";
+
+    if ((a.idle_gaps || []).length) {
+      html += '<h3>Idle Gaps</h3><table><tr><th>From</th><th>To</th><th class="num">Duration</th></tr>';
+      a.idle_gaps.forEach(function (g) {
+        html += "<tr><td>" + esc(clock(g.start_time)) + "</td><td>" + esc(clock(g.end_time)) + '</td><td class="num">' + duration(g.duration) + "</td></tr>";
+      });
+      html += "</table>";
+    }
+
+    html += "<h3>Timeline</h3><table><tr><th>Time</th><th></th><th>Event</th><th>File</th><th>Details</th></tr>";
+    timeline.forEach(function (t) {
+      html += "<tr><td>" + esc(t.time) + "</td><td>" + esc(t.emoji) + "</td><td>" + esc(t.title) + "</td>" +
+        "<td>" + (t.file ? "<code>" + esc(t.file) + "</code>" + (t.location ? " " + esc(t.location) : "") : "") + "</td>" +
+        "<td>" + esc(t.details) + (t.snippet ? " <code>" + esc(t.snippet) + "</code>" : "") + "</td></tr>";
+    });
+    html += "</table>";
+
+    app.innerHTML = html;
+  }
+
+  async function trendsView(query) {
+    const filters = {
+      since: query.get("since") || "",
+      until: query.get("until") || "",
+      project: query.get("project") || "",
+      group_by: query.get("group_by") || "day",
+    };
+    const r = await api("trends" + params(filters));
+
+    let html = '<form class="filters" id="filters">' +
+      '<label>Since <input type="date" name="since" value="' + esc(filters.since) + '"></label>' +
+      '<label>Until <input type="date" name="until" value="' + esc(filters.until) + '"></label>' +
+      '<label>Project <input name="project" value="' + esc(filters.project) + '"></label>' +
+      '<label>Group by <select name="group_by">' +
+      ["day", "week", "project", "file"].map(function (g) {
+        return "<option" + (g === filters.group_by ? " selected" : "") + ">" + g + "</option>";
+      }).join("") +
+      "</select></label><button>Apply</button></form>";
+
+    const t = r.totals;
+    html += '<div class="cards">' +
+      card("Sessions", t.sessions) +
+      card("Active time", duration(t.active_time)) +
+      card("Flow time", duration(t.flow_time)) +
+      card("Idle time", duration(t.idle_time)) +
+      card("Edits", t.file_edits) +
+      card("Commands", t.commands + " (" + t.failed_commands + " failed)") +
+      card("LSP diagnostics", t.diagnostics) +
+      "</div>";
+
+    const groups = r.groups || [];
+    const longest = groups.reduce(function (max, g) { return Math.max(max, g.active_time); }, 0);
+    html += "<h3>By " + esc(r.group_by) + '</h3><table><tr><th>' + esc(r.group_by) + '</th><th class="num">Sessions</th>' +
+      '<th class="num">Active</th><th class="num">Flow</th><th class="num">Edits</th><th class="num">LSP</th><th></th></tr>';
+    groups.forEach(function (g) {
+      const width = longest ? Math.round((g.active_time / longest) * 200) : 0;
+      html += "<tr><td><code>" + esc(g.key) + '</code></td><td class="num">' + g.sessions + "</td>" +
+        '<td class="num">' + duration(g.active_time) + '</td><td class="num">' + duration(g.flow_time) + "</td>" +
+        '<td class="num">' + g.file_edits + '</td><td class="num">' + g.diagnostics + "</td>" +
+        '<td><span class="bar" style="width:' + width + 'px"></span></td></tr>';
+    });
+    html += "</table>";
+
+    if ((t.top_failing_commands || []).length) {
+      html += '<h3>Top Failing Commands</h3><table><tr><th>Command</th><th class="num">Failures</th></tr>';
+      t.top_failing_commands.forEach(function (c) {
+        html += "<tr><td><code>" + esc(c.command) + '</code></td><td class="num">' + c.failures + "</td></tr>";
+      });
+      html += "</table>";
+    }
+
+    const trend = r.diagnostics_trend || [];
+    if (trend.length) {
+      html += '<h3>Diagnostics Trend</h3><table><tr><th>Day</th><th class="num">Errors</th><th class="num">Warnings</th><th></th></tr>';
+      trend.forEach(function (p) {
+        html += "<tr><td>" + esc(p.day) + '</td><td class="num">' + p.errors + '</td><td class="num">' + p.warnings + "</td>" +
+          '<td><span class="bar" style="width:' + p.errors * 4 + 'px"></span><span class="bar warn" style="width:' + p.warnings * 4 + 'px"></span></td></tr>';
+      });
+      html += "</table>";
+    }
+
+    app.innerHTML = html;
+
+    document.getElementById("filters").addEventListener("submit", function (e) {
+      e.preventDefault();
+      const data = new FormData(e.target);
+      location.hash = "#/trends" + params({
+        since: data.get("since"),
+        until: data.get("until"),
+        project: data.get("project"),
+        group_by: data.get("group_by"),
+      });
+    });
+  }
+
+  async function searchView(query) {
+    const term = query.get("q") || "";
+    document.getElementById("search-input").value = term;
+    const hits = await api("search" + params({ q: term }));
+
+    let html = "<h2>" + hits.length + " results for “" + esc(term) + "”</h2>";
+    html += "<table><tr><th>Session</th><th>When</th><th>Type</th><th>File</th><th>Match</th></tr>";
+    hits.forEach(function (h) {
+      html += "<tr><td>" + sessionLink(h.session_id) + "</td><td>" + esc(when(h.timestamp)) + "</td>" +
+        "<td>" + esc(h.type) + "</td><td>" + (h.filename ? "<code>" + esc(base(h.filename)) + "</code>" + (h.line ? ":" + h.line : "") : "") + "</td>" +
+        "<td>" + esc(h.text) + "</td></tr>";
+    });
+    html += "</table>";
+    app.innerHTML = html;
+  }
+
+  // --- routing ---------------------------------------------------------------
+
+  async function route() {
+    const hash = location.hash.replace(/^#/, "") || "/";
+    const qIndex = hash.indexOf("?");
+    const path = qIndex >= 0 ? hash.slice(0, qIndex) : hash;
+    const query = new URLSearchParams(qIndex >= 0 ? hash.slice(qIndex + 1) : "");
+
+    try {
+      if (path.startsWith("/session/")) {
+        await sessionView(decodeURIComponent(path.slice("/session/".length)));
+      } else if (path === "/trends") {
+        await trendsView(query);
+      } else if (path === "/search") {
+        await searchView(query);
+      } else {
+        await sessionsView(query);
+      }
+    } catch (err) {
+      app.innerHTML = '<p class="error">' + esc(err.message) + "</p>";
+    }
+  }
+
+  document.getElementById("search-form").addEventListener("submit", function (e) {
+    e.preventDefault();
+    const term = document.getElementById("search-input").value.trim();
+    if (term) location.hash = "#/search" + params({ q: term });
+  });
+
+  window.addEventListener("hashchange", route);
+  route();
+})();
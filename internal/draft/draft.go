@@ -0,0 +1,441 @@
+// Package draft collects the facts behind a pull-request description, commit message or
+// postmortem from a recorded session: the problem, the investigation, the root cause, the
+// files changed and the commands that verified the fix. Everything is derived from the
+// recorded events, so the same session always produces the same draft.
+package draft
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/aggregator"
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+// Draft kinds accepted by Build.
+const (
+	KindPR         = "pr"
+	KindCommit     = "commit"
+	KindPostmortem = "postmortem"
+)
+
+// Kinds returns the supported draft kinds.
+func Kinds() []string {
+	return []string{KindPR, KindCommit, KindPostmortem}
+}
+
+// maxSteps limits the investigation steps listed in a draft.
+const maxSteps = 30
+
+// Annotation tags that mark a note as describing the problem or its root cause.
+var (
+	problemTags   = []string{"problem", "bug", "issue", "symptom", "incident"}
+	rootCauseTags = []string{"rootcause", "root-cause", "root_cause", "cause"}
+)
+
+// Draft is the view model of a draft template.
+type Draft struct {
+	Kind        string
+	SessionID   string
+	Project     string // Base name of the project directory
+	ProjectPath string
+	Start       time.Time
+	End         time.Time     // Zero while the session is active
+	Elapsed     time.Duration // End minus start, zero while active
+	IdleTime    time.Duration
+
+	Title        string              // Suggested summary line
+	Problem      []Note              // Notes tagged as the problem, else the first untagged note
+	Errors       []Diagnostic        // Distinct error diagnostics, in order of first appearance
+	RootCause    []Note              // Notes tagged as the root cause, else error-fix notes
+	Steps        []Step              // Chronological investigation, at most maxSteps
+	MoreSteps    int                 // Steps left out of Steps
+	Files        []FileChange        // Edited files, most edits first
+	Commits      []aggregator.Commit // Commits made from the terminal
+	FixedTests   []TestRun           // Test commands that failed and later passed
+	FailingTests []TestRun           // Test commands whose last run failed
+	Verification []string            // Test and build commands whose last run passed
+	Notes        []Note              // Every annotation, in order
+	Tags         []string
+}
+
+// Note is an annotation and when it was written.
+type Note struct {
+	Time time.Time
+	Text string
+}
+
+// Diagnostic is an LSP error and where it was first reported.
+type Diagnostic struct {
+	Time     time.Time
+	Location string // Project-relative file:line
+	Message  string
+	Count    int // How often it was reported
+}
+
+// Step is one entry of the investigation: a note, a command, or a run of edits to one file.
+type Step struct {
+	Time   time.Time
+	Kind   string // "note", "command" or "edit"
+	Text   string
+	Failed bool // Commands that exited with a non-zero status
+}
+
+// FileChange summarizes the edits made to one file.
+type FileChange struct {
+	File   string // Project-relative path
+	Edits  int
+	Blocks int
+	Time   time.Duration // Focus time from the aggregator's analytics
+}
+
+// TestRun summarizes the runs of one test command.
+type TestRun struct {
+	Command   string
+	Runs      int
+	Failures  int
+	FirstFail time.Time
+	LastRun   time.Time
+	Passed    bool // Whether the last run passed
+}
+
+// Build derives a draft of the given kind from a session.
+func Build(session *models.Session, agg *aggregator.Aggregator, kind string) (*Draft, error) {
+	if !validKind(kind) {
+		return nil, fmt.Errorf("unknown draft kind %q: use %s", kind, strings.Join(Kinds(), ", "))
+	}
+
+	blocks, analytics := agg.AggregateSession(session)
+
+	d := &Draft{
+		Kind:        kind,
+		SessionID:   session.ID,
+		Project:     filepath.Base(session.ProjectPath),
+		ProjectPath: session.ProjectPath,
+		Start:       session.StartTime,
+		End:         session.EndTime,
+		IdleTime:    analytics.TotalIdleTime,
+		Commits:     aggregator.ExtractCommits(session.Events),
+		Tags:        aggregator.ExtractTags(session.Events),
+	}
+	if !session.EndTime.IsZero() {
+		d.Elapsed = session.EndTime.Sub(session.StartTime)
+	}
+
+	d.collectNotes(session.Events, analytics)
+	d.Errors = collectErrors(session.Events, session.ProjectPath)
+	d.Files = collectFiles(blocks, analytics, session.ProjectPath)
+	d.collectTests(session.Events)
+	d.Steps = buildSteps(session.Events, blocks, session.ProjectPath)
+	if len(d.Steps) > maxSteps {
+		d.MoreSteps = len(d.Steps) - maxSteps
+		d.Steps = d.Steps[:maxSteps]
+	}
+	d.Title = d.suggestTitle()
+
+	return d, nil
+}
+
+func validKind(kind string) bool {
+	for _, k := range Kinds() {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// collectNotes sorts annotations into the problem and root cause. Without tagged notes the
+// first untagged annotation is taken as the problem and the aggregator's error-fix notes as the cause.
+func (d *Draft) collectNotes(events []models.Event, analytics *models.SessionAnalytics) {
+	var untagged []Note
+	for _, event := range events {
+		if event.Type != "annotation" || strings.TrimSpace(event.Data.Note) == "" {
+			continue
+		}
+		note := Note{Time: event.Timestamp, Text: strings.TrimSpace(event.Data.Note)}
+		d.Notes = append(d.Notes, note)
+
+		tags := aggregator.ParseTags(note.Text)
+		switch {
+		case hasTag(tags, rootCauseTags) || hasPrefixFold(note.Text, "root cause"):
+			d.RootCause = append(d.RootCause, note)
+		case hasTag(tags, problemTags):
+			d.Problem = append(d.Problem, note)
+		default:
+			untagged = append(untagged, note)
+		}
+	}
+
+	if len(d.Problem) == 0 && len(untagged) > 0 {
+		d.Problem = append(d.Problem, untagged[0])
+	}
+	if len(d.RootCause) == 0 {
+		for _, pattern := range analytics.ErrorCorrections {
+			d.RootCause = append(d.RootCause, Note{Time: pattern.Timestamp, Text: pattern.Annotation})
+		}
+	}
+}
+
+// collectErrors returns the distinct error diagnostics in order of first appearance.
+func collectErrors(events []models.Event, projectPath string) []Diagnostic {
+	var errors []Diagnostic
+	index := make(map[string]int)
+
+	for _, event := range events {
+		if event.Type != "lsp_diagnostic" || !strings.EqualFold(event.Data.Level, "error") {
+			continue
+		}
+		location := aggregator.RelativePath(projectPath, event.Data.Filename)
+		if event.Data.Line > 0 {
+			location = fmt.Sprintf("%s:%d", location, event.Data.Line)
+		}
+		key := location + "\x00" + event.Data.Message
+		if i, ok := index[key]; ok {
+			errors[i].Count++
+			continue
+		}
+		index[key] = len(errors)
+		errors = append(errors, Diagnostic{Time: event.Timestamp, Location: location, Message: event.Data.Message, Count: 1})
+	}
+	return errors
+}
+
+// collectFiles totals the activity blocks per file, most edits first.
+func collectFiles(blocks []models.ActivityBlock, analytics *models.SessionAnalytics, projectPath string) []FileChange {
+	index := make(map[string]int)
+	var files []FileChange
+
+	for _, block := range blocks {
+		i, ok := index[block.Filename]
+		if !ok {
+			i = len(files)
+			index[block.Filename] = i
+			files = append(files, FileChange{
+				File: aggregator.RelativePath(projectPath, block.Filename),
+				Time: time.Duration(analytics.MainFiles[block.Filename]) * time.Second,
+			})
+		}
+		files[i].Edits += block.EventCount
+		files[i].Blocks++
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].Edits != files[j].Edits {
+			return files[i].Edits > files[j].Edits
+		}
+		return files[i].File < files[j].File
+	})
+	return files
+}
+
+// collectTests follows the outcome of every test and build command with a known exit code.
+func (d *Draft) collectTests(events []models.Event) {
+	runs := make(map[string]*TestRun)
+	var order []string
+	verified := make(map[string]time.Time)
+
+	for _, event := range events {
+		if event.Type != "terminal_command" || event.Data.ExitCode == nil {
+			continue
+		}
+		command := strings.TrimSpace(event.Data.Command)
+		passed := *event.Data.ExitCode == 0
+
+		if isCheckCommand(command) {
+			if passed {
+				verified[command] = event.Timestamp
+			} else {
+				delete(verified, command)
+			}
+		}
+		if !isTestCommand(command) {
+			continue
+		}
+
+		run, ok := runs[command]
+		if !ok {
+			run = &TestRun{Command: command}
+			runs[command] = run
+			order = append(order, command)
+		}
+		run.Runs++
+		run.LastRun = event.Timestamp
+		run.Passed = passed
+		if !passed {
+			if run.Failures == 0 {
+				run.FirstFail = event.Timestamp
+			}
+			run.Failures++
+		}
+	}
+
+	for _, command := range order {
+		run := runs[command]
+		switch {
+		case !run.Passed:
+			d.FailingTests = append(d.FailingTests, *run)
+		case run.Failures > 0:
+			d.FixedTests = append(d.FixedTests, *run)
+		}
+	}
+
+	for command := range verified {
+		d.Verification = append(d.Verification, command)
+	}
+	sort.Slice(d.Verification, func(i, j int) bool {
+		ti, tj := verified[d.Verification[i]], verified[d.Verification[j]]
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return d.Verification[i] < d.Verification[j]
+	})
+}
+
+// buildSteps interleaves notes, commands and edit runs in time order. Consecutive activity
+// blocks in the same file form one edit step.
+func buildSteps(events []models.Event, blocks []models.ActivityBlock, projectPath string) []Step {
+	var steps []Step
+
+	for _, event := range events {
+		switch event.Type {
+		case "annotation":
+			if note := strings.TrimSpace(event.Data.Note); note != "" {
+				steps = append(steps, Step{Time: event.Timestamp, Kind: "note", Text: note})
+			}
+		case "terminal_command":
+			failed := event.Data.ExitCode != nil && *event.Data.ExitCode != 0
+			steps = append(steps, Step{Time: event.Timestamp, Kind: "command", Text: event.Data.Command, Failed: failed})
+		}
+	}
+
+	var run *models.ActivityBlock
+	var edits int
+	flush := func() {
+		if run == nil {
+			return
+		}
+		text := fmt.Sprintf("Edited `%s` (%d edits)", aggregator.RelativePath(projectPath, run.Filename), edits)
+		steps = append(steps, Step{Time: run.StartTime, Kind: "edit", Text: text})
+		run = nil
+	}
+	for i := range blocks {
+		block := &blocks[i]
+		if run != nil && block.Filename == run.Filename && !interrupted(events, run.StartTime, block.StartTime) {
+			edits += block.EventCount
+			continue
+		}
+		flush()
+		run = block
+		edits = block.EventCount
+	}
+	flush()
+
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].Time.Before(steps[j].Time)
+	})
+	return steps
+}
+
+// interrupted reports whether a note or command was recorded between two edit blocks.
+func interrupted(events []models.Event, from, to time.Time) bool {
+	for _, event := range events {
+		if event.Timestamp.Before(from) || !event.Timestamp.Before(to) {
+			continue
+		}
+		if event.Type == "annotation" || event.Type == "terminal_command" {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestTitle picks the summary line: the first commit message, else the root cause or
+// problem note, else the most edited file.
+func (d *Draft) suggestTitle() string {
+	for _, commit := range d.Commits {
+		if line := firstLine(commit.Message); line != "" {
+			return line
+		}
+	}
+	for _, notes := range [][]Note{d.RootCause, d.Problem} {
+		if len(notes) > 0 {
+			if line := stripTags(firstLine(notes[0].Text)); line != "" {
+				return line
+			}
+		}
+	}
+	if len(d.Files) > 0 {
+		return "Update " + filepath.Base(d.Files[0].File)
+	}
+	return "Changes from session " + d.SessionID
+}
+
+// testRunners are commands, or subcommands, that run a test suite.
+var testRunners = []string{"pytest", "jest", "vitest", "mocha", "rspec", "phpunit", "ctest", "tox", "busted", "gotestsum"}
+
+// isTestCommand reports whether any command in a command line runs tests, e.g.
+// `go test ./...`, `npm run test:unit`, `make test` or `pytest -x`.
+func isTestCommand(command string) bool {
+	for _, word := range strings.Fields(command) {
+		word = strings.Trim(word, `"'`)
+		if word == "test" || strings.HasPrefix(word, "test:") || word == "tests" {
+			return true
+		}
+		base := filepath.Base(word)
+		for _, runner := range testRunners {
+			if base == runner {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isCheckCommand reports whether a command line verifies the code: tests, builds, vet and lint.
+func isCheckCommand(command string) bool {
+	if isTestCommand(command) {
+		return true
+	}
+	for _, word := range strings.Fields(command) {
+		switch strings.Trim(word, `"'`) {
+		case "build", "vet", "lint", "check", "tsc", "clippy", "golangci-lint", "eslint", "ruff", "mypy":
+			return true
+		}
+	}
+	return false
+}
+
+func hasTag(tags, wanted []string) bool {
+	for _, tag := range tags {
+		for _, w := range wanted {
+			if tag == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(line)
+}
+
+// stripTags removes #hashtags from a note so it reads as a sentence.
+func stripTags(s string) string {
+	var words []string
+	for _, word := range strings.Fields(s) {
+		if !strings.HasPrefix(word, "#") {
+			words = append(words, word)
+		}
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,255 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+// CastExporter exports sessions as asciicast v2 recordings ({session_id}.cast) that can be
+// played with asciinema. Each grouped timeline row becomes one rendered terminal frame.
+type CastExporter struct {
+	Width   int
+	Height  int
+	MaxIdle time.Duration // Longer pauses between frames are shortened to this
+}
+
+// NewCastExporter creates a cast exporter with an 100x30 terminal and a 2 second idle limit.
+func NewCastExporter() *CastExporter {
+	return &CastExporter{Width: 100, Height: 30, MaxIdle: 2 * time.Second}
+}
+
+// castHeader is the first line of an asciicast v2 file.
+type castHeader struct {
+	Version       int               `json:"version"`
+	Width         int               `json:"width"`
+	Height        int               `json:"height"`
+	Timestamp     int64             `json:"timestamp"`
+	IdleTimeLimit float64           `json:"idle_time_limit,omitempty"`
+	Title         string            `json:"title"`
+	Env           map[string]string `json:"env"`
+}
+
+// Export writes the session to {session_id}.cast in savePath.
+func (e *CastExporter) Export(session *models.Session, savePath string) error {
+	return e.WriteFile(filepath.Join(savePath, session.ID+".cast"), session)
+}
+
+// WriteFile writes the session as an asciicast v2 recording to path. When the write
+// fails, a regular file at path is removed so no truncated recording is left behind.
+func (e *CastExporter) WriteFile(path string, session *models.Session) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = e.Write(file, session)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if info, statErr := os.Stat(path); err != nil && statErr == nil && info.Mode().IsRegular() {
+		_ = os.Remove(path)
+	}
+	return err
+}
+
+// Artifacts returns the path of the recording.
+func (e *CastExporter) Artifacts(session *models.Session, savePath string) []string {
+	return []string{filepath.Join(savePath, session.ID+".cast")}
+}
+
+// Write renders the session as an asciicast v2 stream.
+func (e *CastExporter) Write(w io.Writer, session *models.Session) error {
+	rows := GroupTimeline(session.Events)
+	bw := bufio.NewWriter(w)
+
+	header := castHeader{
+		Version:   2,
+		Width:     e.Width,
+		Height:    e.Height,
+		Timestamp: session.StartTime.Unix(),
+		Title:     "capytrace: " + session.ID,
+		Env:       map[string]string{"TERM": "xterm-256color"},
+	}
+	if e.MaxIdle > 0 {
+		header.IdleTimeLimit = e.MaxIdle.Seconds()
+	}
+	data, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	if _, err := bw.Write(append(data, '\n')); err != nil {
+		return err
+	}
+
+	var offset time.Duration
+	for i, row := range rows {
+		if i > 0 {
+			gap := row.Start.Sub(rows[i-1].Start)
+			if gap < 0 {
+				gap = 0
+			}
+			if e.MaxIdle > 0 && gap > e.MaxIdle {
+				gap = e.MaxIdle
+			}
+			offset += gap
+		}
+
+		frame := e.renderFrame(session, rows, i)
+		data, err := json.Marshal([]interface{}{math.Round(offset.Seconds()*1000) / 1000, "o", frame})
+		if err != nil {
+			return err
+		}
+		if _, err := bw.Write(append(data, '\n')); err != nil {
+			return err
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+	return nil
+}
+
+// renderFrame draws the screen shown while row i is the latest event.
+func (e *CastExporter) renderFrame(session *models.Session, rows []TimelineEvent, i int) string {
+	const (
+		clear   = "\x1b[2J\x1b[H"
+		reset   = "\x1b[0m"
+		bold    = "\x1b[1m"
+		dim     = "\x1b[2m"
+		inverse = "\x1b[7m"
+		red     = "\x1b[31m"
+		green   = "\x1b[32m"
+		yellow  = "\x1b[33m"
+		cyan    = "\x1b[36m"
+	)
+
+	row := rows[i]
+	var lines []string
+
+	title := fmt.Sprintf(" 🦦 capytrace · %s · %s ", session.ID, row.Start.Format("2006-01-02 15:04:05"))
+	lines = append(lines, inverse+pad(title, e.Width)+reset, "")
+
+	// Body for the current row
+	switch row.Type {
+	case "file_edit":
+		lines = append(lines, bold+cyan+"📄 "+row.File+reset+dim+"  "+row.Location+"  ("+row.Details+")"+reset)
+		lines = append(lines, dim+strings.Repeat("─", e.Width)+reset)
+		if row.Snippet != "" {
+			lines = append(lines, dim+" "+strings.TrimPrefix(strings.SplitN(row.Location, ":", 2)[0], "L")+" │ "+reset+row.Snippet)
+		}
+	case "terminal_command":
+		lines = append(lines, bold+"$ "+row.Details+reset)
+		if code, ok := exitCodeAt(session.Events, row); ok {
+			if code == 0 {
+				lines = append(lines, green+"✓ exit 0"+reset)
+			} else {
+				lines = append(lines, fmt.Sprintf("%s✗ exit %d%s", red, code, reset))
+			}
+		}
+	case "annotation", "session_gap":
+		banner := " " + row.Emoji + "  " + row.Details + " "
+		lines = append(lines, yellow+inverse+pad(banner, e.Width)+reset)
+	case "lsp_diagnostic":
+		lines = append(lines, red+row.Emoji+" "+row.Details+reset)
+		if row.Location != "" {
+			lines = append(lines, dim+"   at "+row.Location+reset)
+		}
+	default:
+		line := bold + row.Emoji + " " + row.Title + reset
+		if row.Details != "" {
+			line += "  " + row.Details
+		}
+		lines = append(lines, line)
+	}
+
+	// Recent timeline rows fill the rest of the screen
+	lines = append(lines, "", dim+"── Timeline "+strings.Repeat("─", maxInt(e.Width-12, 0))+reset)
+	room := e.Height - len(lines)
+	start := i - room + 1
+	if start < 0 {
+		start = 0
+	}
+	for j := start; j <= i; j++ {
+		r := rows[j]
+		line := fmt.Sprintf("%s %s %s", r.Time, r.Emoji, r.Title)
+		if r.File != "" {
+			line += "  " + filepath.Base(r.File)
+		}
+		if r.Details != "" && r.Type != "file_edit" {
+			line += "  " + r.Details
+		}
+		if j == i {
+			lines = append(lines, bold+"▶ "+line+reset)
+		} else {
+			lines = append(lines, dim+"  "+line+reset)
+		}
+	}
+
+	for k, line := range lines {
+		lines[k] = ClipLine(line, e.Width)
+	}
+	return clear + strings.Join(lines, "\r\n")
+}
+
+// exitCodeAt finds the exit code of the terminal command shown in row.
+func exitCodeAt(events []models.Event, row TimelineEvent) (int, bool) {
+	for _, ev := range events {
+		if ev.Type == "terminal_command" && ev.Timestamp.Equal(row.Start) && ev.Data.ExitCode != nil {
+			return *ev.Data.ExitCode, true
+		}
+	}
+	return 0, false
+}
+
+// pad right-pads s with spaces to width characters.
+func pad(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+// ClipLine truncates a line to width visible characters, leaving ANSI escape sequences
+// intact. Tabs and line breaks become spaces so the line stays on one terminal row.
+func ClipLine(line string, width int) string {
+	var sb strings.Builder
+	visible := 0
+	inEscape := false
+	for _, r := range line {
+		switch {
+		case r == '\x1b':
+			inEscape = true
+		case inEscape:
+			if r >= '@' && r <= '~' && r != '[' {
+				inEscape = false
+			}
+		default:
+			if r == '\t' || r == '\n' || r == '\r' {
+				r = ' '
+			}
+			if visible >= width {
+				continue
+			}
+			visible++
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,195 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/aggregator"
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+// CSV table names; each is written to {prefix}_{table}{extension}.
+const (
+	CSVEvents = "events"
+	CSVBlocks = "blocks"
+	CSVFiles  = "files"
+)
+
+// CSVExporter exports sessions as spreadsheet tables: {id}_events, {id}_blocks and
+// {id}_files, with RFC 4180 quoting. Every row starts with the session ID so tables of
+// several sessions can be combined.
+type CSVExporter struct {
+	Comma     rune   // Field separator
+	Extension string // File extension including the dot
+	config    *aggregator.AggregatorConfig
+}
+
+// NewCSVExporter creates a comma-separated exporter writing .csv files.
+func NewCSVExporter(config *aggregator.AggregatorConfig) *CSVExporter {
+	return &CSVExporter{Comma: ',', Extension: ".csv", config: config}
+}
+
+// NewTSVExporter creates a tab-separated exporter writing .tsv files.
+func NewTSVExporter(config *aggregator.AggregatorConfig) *CSVExporter {
+	return &CSVExporter{Comma: '\t', Extension: ".tsv", config: config}
+}
+
+// Export writes the three tables for one session to savePath.
+func (e *CSVExporter) Export(session *models.Session, savePath string) error {
+	_, err := e.WriteTables([]*models.Session{session}, savePath, session.ID)
+	return err
+}
+
+// Artifacts returns the paths of the three tables.
+func (e *CSVExporter) Artifacts(session *models.Session, savePath string) []string {
+	return e.paths(savePath, session.ID)
+}
+
+// WriteTables writes the events, blocks and files tables of many sessions into dir,
+// one file per table named {prefix}_{table}{extension}, and returns their paths.
+func (e *CSVExporter) WriteTables(sessions []*models.Session, dir, prefix string) ([]string, error) {
+	agg := aggregator.New(e.config)
+
+	var events, blocks, files [][]string
+	for _, session := range sessions {
+		events = append(events, eventRows(session)...)
+
+		sessionBlocks, analytics := agg.AggregateSession(session)
+		for _, block := range sessionBlocks {
+			blocks = append(blocks, blockRow(session.ID, block))
+		}
+		files = append(files, fileRows(session.ID, analytics)...)
+	}
+
+	tables := map[string][][]string{
+		CSVEvents: append([][]string{eventColumns}, events...),
+		CSVBlocks: append([][]string{blockColumns}, blocks...),
+		CSVFiles:  append([][]string{fileColumns}, files...),
+	}
+
+	paths := e.paths(dir, prefix)
+	for i, table := range []string{CSVEvents, CSVBlocks, CSVFiles} {
+		if err := e.writeTable(paths[i], tables[table]); err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// paths returns the table paths in CSVEvents, CSVBlocks, CSVFiles order.
+func (e *CSVExporter) paths(dir, prefix string) []string {
+	return []string{
+		filepath.Join(dir, prefix+"_"+CSVEvents+e.Extension),
+		filepath.Join(dir, prefix+"_"+CSVBlocks+e.Extension),
+		filepath.Join(dir, prefix+"_"+CSVFiles+e.Extension),
+	}
+}
+
+// writeTable writes rows to path with the exporter's separator.
+func (e *CSVExporter) writeTable(path string, rows [][]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(file)
+	w.Comma = e.Comma
+	if err := w.WriteAll(rows); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return file.Close()
+}
+
+var eventColumns = []string{
+	"session_id", "type", "timestamp", "source",
+	"filename", "line", "column", "line_count", "changed_tick", "line_text",
+	"file_type", "message", "level", "command", "exit_code", "note",
+	"prev_line", "prev_column",
+}
+
+// eventRows flattens every event of a session, one row per event.
+func eventRows(session *models.Session) [][]string {
+	rows := make([][]string, 0, len(session.Events))
+	for _, ev := range session.Events {
+		d := ev.Data
+		exitCode := ""
+		if d.ExitCode != nil {
+			exitCode = strconv.Itoa(*d.ExitCode)
+		}
+		rows = append(rows, []string{
+			session.ID, ev.Type, ev.Timestamp.Format(time.RFC3339), ev.Source,
+			d.Filename, intField(ev, "line", d.Line), intField(ev, "column", d.Column),
+			intField(ev, "line_count", d.LineCount), intField(ev, "changed_tick", d.ChangedTick), d.LineText,
+			d.FileType, d.Message, d.Level, d.Command, exitCode, d.Note,
+			intField(ev, "prev_line", d.PrevLine), intField(ev, "prev_column", d.PrevColumn),
+		})
+	}
+	return rows
+}
+
+var blockColumns = []string{
+	"session_id", "start_time", "end_time", "duration_seconds", "filename",
+	"event_count", "start_tick", "end_tick", "delta_tick", "velocity", "closed_by",
+}
+
+// blockRow flattens an activity block.
+func blockRow(sessionID string, block models.ActivityBlock) []string {
+	return []string{
+		sessionID,
+		block.StartTime.Format(time.RFC3339),
+		block.EndTime.Format(time.RFC3339),
+		strconv.FormatFloat(block.Duration.Seconds(), 'f', 3, 64),
+		block.Filename,
+		strconv.Itoa(block.EventCount),
+		strconv.Itoa(block.StartTick),
+		strconv.Itoa(block.EndTick),
+		strconv.Itoa(block.DeltaTick),
+		strconv.FormatFloat(block.Velocity, 'f', 2, 64),
+		block.ClosedBy,
+	}
+}
+
+var fileColumns = []string{"session_id", "filename", "focus_seconds"}
+
+// fileRows lists the focus time per main file, longest first.
+func fileRows(sessionID string, analytics *models.SessionAnalytics) [][]string {
+	names := make([]string, 0, len(analytics.MainFiles))
+	for name := range analytics.MainFiles {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if analytics.MainFiles[names[i]] != analytics.MainFiles[names[j]] {
+			return analytics.MainFiles[names[i]] > analytics.MainFiles[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	rows := make([][]string, 0, len(names))
+	for _, name := range names {
+		rows = append(rows, []string{sessionID, name, strconv.Itoa(analytics.MainFiles[name])})
+	}
+	return rows
+}
+
+// intColumns lists the integer columns each event type records. Zero is a real value
+// there (columns are 0-based), so only columns an event type does not record stay empty.
+var intColumns = map[string][]string{
+	"file_edit":      {"line", "column", "line_count", "changed_tick"},
+	"cursor_move":    {"line", "column", "prev_line", "prev_column"},
+	"lsp_diagnostic": {"line", "column"},
+}
+
+// intField renders an integer column, leaving it empty when the event type does not
+// record it.
+func intField(ev models.Event, column string, n int) string {
+	if !containsString(intColumns[ev.Type], column) {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
@@ -0,0 +1,66 @@
+package exporter
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/report"
+)
+
+//go:embed templates/diff.md
+var diffMarkdownTemplate []byte
+
+// DiffTemplateName is the template for `capytrace diff --format markdown`.
+const DiffTemplateName = "diff.md"
+
+// RenderDiff renders a session diff as "markdown" or "json". The Markdown template is
+// taken from dir when it has a diff.md.
+func RenderDiff(d *report.Diff, format, dir string) ([]byte, error) {
+	switch format {
+	case "json":
+		return json.MarshalIndent(d, "", "  ")
+	case "markdown", "md", "":
+		content, err := renderTemplate(dir, DiffTemplateName, diffMarkdownTemplate, d)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(content), nil
+	default:
+		return nil, fmt.Errorf("unknown diff format %q: use markdown or json", format)
+	}
+}
+
+// formatOffset formats time elapsed since a session's start as +H:MM.
+func formatOffset(d time.Duration) string {
+	return fmt.Sprintf("+%d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// formatDelta formats how much longer (+) or shorter (−) b took than a.
+func formatDelta(a, b time.Duration) string {
+	switch d := b - a; {
+	case d > 0:
+		return "+" + formatDuration(d)
+	case d < 0:
+		return "−" + formatDuration(-d)
+	default:
+		return "="
+	}
+}
+
+// formatCountDelta formats the difference between two counts.
+func formatCountDelta(a, b int) string {
+	if a == b {
+		return "="
+	}
+	return fmt.Sprintf("%+d", b-a)
+}
+
+// formatFirstOffset formats an optional offset, or a dash when there is none.
+func formatFirstOffset(d *time.Duration) string {
+	if d == nil {
+		return "—"
+	}
+	return formatOffset(*d)
+}
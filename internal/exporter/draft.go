@@ -0,0 +1,45 @@
+package exporter
+
+import (
+	_ "embed"
+	"fmt"
+
+	"github.com/andev0x/capytrace.nvim/internal/draft"
+)
+
+//go:embed templates/draft_pr.md
+var draftPRTemplate []byte
+
+//go:embed templates/draft_commit.md
+var draftCommitTemplate []byte
+
+// Draft template file names, both embedded and looked up in a user template directory.
+const (
+	DraftPRTemplateName     = "draft_pr.md"
+	DraftCommitTemplateName = "draft_commit.md"
+)
+
+// draftTemplate returns the template name and embedded default for a draft kind.
+// Postmortem drafts are rendered by PostmortemExporter.Render from postmortem.md.
+func draftTemplate(kind string) (string, []byte, error) {
+	switch kind {
+	case draft.KindPR:
+		return DraftPRTemplateName, draftPRTemplate, nil
+	case draft.KindCommit:
+		return DraftCommitTemplateName, draftCommitTemplate, nil
+	case draft.KindPostmortem:
+		return "", nil, fmt.Errorf("postmortem drafts are rendered by PostmortemExporter")
+	default:
+		return "", nil, fmt.Errorf("unknown draft kind %q", kind)
+	}
+}
+
+// RenderDraft renders a draft with the template for its kind, preferring the copy in dir.
+func RenderDraft(d *draft.Draft, dir string) (string, error) {
+	name, fallback, err := draftTemplate(d.Kind)
+	if err != nil {
+		return "", err
+	}
+
+	return renderTemplate(dir, name, fallback, d)
+}
@@ -1,5 +1,5 @@
 // Package exporter provides interfaces and implementations for exporting
-// debugging sessions to various formats (Markdown, JSON, SQLite).
+// debugging sessions to various formats (Markdown, JSON, SQLite, HTML, asciicast).
 package exporter
 
 import "github.com/andev0x/capytrace.nvim/internal/models"
@@ -0,0 +1,38 @@
+package exporter
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/andev0x/capytrace.nvim/internal/aggregator"
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+//go:embed templates/session.html
+var sessionHTMLTemplate []byte
+
+// HTMLExporter exports sessions as standalone HTML pages ({session_id}.html).
+type HTMLExporter struct {
+	// TemplateDir overrides the session's template directory; a session.html found there
+	// replaces the embedded template.
+	TemplateDir string
+}
+
+// Export writes the session to {session_id}.html in savePath.
+func (e *HTMLExporter) Export(session *models.Session, savePath string) error {
+	data := newTemplateData(session, aggregator.New(aggregator.DefaultConfig()))
+
+	content, err := renderHTMLTemplate(templateDir(e.TemplateDir, session), SessionHTMLTemplateName, sessionHTMLTemplate, data)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(savePath, fmt.Sprintf("%s.html", session.ID)), []byte(content), 0644)
+}
+
+// Artifacts returns the path of the HTML page.
+func (e *HTMLExporter) Artifacts(session *models.Session, savePath string) []string {
+	return []string{filepath.Join(savePath, session.ID+".html")}
+}
@@ -0,0 +1,309 @@
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/andev0x/capytrace.nvim/internal/aggregator"
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+// Calendar granularities.
+const (
+	GranularitySession = "session" // One event per active period of a session
+	GranularityBlock   = "block"   // One event per run of activity blocks in the same file
+)
+
+// OptionCalendarGranularity is the session export option choosing the ics granularity.
+const OptionCalendarGranularity = "ics.granularity"
+
+// minCalendarEvent is the shortest event written, so brief activity still shows up.
+const minCalendarEvent = time.Minute
+
+// ICSExporter exports sessions as iCalendar files ({session_id}.ics) for time tracking.
+// Idle gaps longer than the aggregator's idle threshold split events, so they are never
+// counted as working time. UIDs are derived from the session ID and the event's index,
+// so re-exporting a session updates its events instead of duplicating them.
+type ICSExporter struct {
+	Granularity string // GranularitySession or GranularityBlock; defaults to the session's export option
+	config      *aggregator.AggregatorConfig
+}
+
+// NewICSExporter creates an iCalendar exporter.
+func NewICSExporter(config *aggregator.AggregatorConfig) *ICSExporter {
+	return &ICSExporter{config: config}
+}
+
+// calendarEvent is one VEVENT.
+type calendarEvent struct {
+	UID         string
+	Start, End  time.Time
+	Summary     string
+	Description string
+	Categories  []string
+}
+
+// Export writes the session to {session_id}.ics in savePath.
+func (e *ICSExporter) Export(session *models.Session, savePath string) error {
+	file, err := os.Create(filepath.Join(savePath, session.ID+".ics"))
+	if err != nil {
+		return err
+	}
+	if err := e.Write(file, []*models.Session{session}); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Artifacts returns the path of the calendar file.
+func (e *ICSExporter) Artifacts(session *models.Session, savePath string) []string {
+	return []string{filepath.Join(savePath, session.ID+".ics")}
+}
+
+// Write renders the sessions as one VCALENDAR.
+func (e *ICSExporter) Write(w io.Writer, sessions []*models.Session) error {
+	bw := bufio.NewWriter(w)
+	line := func(s string) {
+		bw.WriteString(foldICS(s))
+		bw.WriteString("\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//capytrace.nvim//capytrace//EN")
+	line("CALSCALE:GREGORIAN")
+
+	for _, session := range sessions {
+		events, err := e.events(session)
+		if err != nil {
+			return err
+		}
+		stamp := session.EndTime
+		if stamp.IsZero() {
+			stamp = session.StartTime
+		}
+
+		for _, ev := range events {
+			line("BEGIN:VEVENT")
+			line("UID:" + ev.UID)
+			line("DTSTAMP:" + icsTime(stamp))
+			line("DTSTART:" + icsTime(ev.Start))
+			line("DTEND:" + icsTime(ev.End))
+			line("SUMMARY:" + escapeICS(ev.Summary))
+			line("DESCRIPTION:" + escapeICS(ev.Description))
+			if len(ev.Categories) > 0 {
+				escaped := make([]string, len(ev.Categories))
+				for i, category := range ev.Categories {
+					escaped[i] = escapeICS(category)
+				}
+				line("CATEGORIES:" + strings.Join(escaped, ","))
+			}
+			line("END:VEVENT")
+		}
+	}
+
+	line("END:VCALENDAR")
+	return bw.Flush()
+}
+
+// events builds the calendar events of one session at the configured granularity.
+func (e *ICSExporter) events(session *models.Session) ([]calendarEvent, error) {
+	granularity := e.Granularity
+	if granularity == "" {
+		granularity = session.ExportOptions[OptionCalendarGranularity]
+	}
+
+	switch granularity {
+	case GranularitySession, "":
+		return e.sessionEvents(session), nil
+	case GranularityBlock:
+		return e.blockEvents(session), nil
+	default:
+		return nil, fmt.Errorf("unknown calendar granularity %q: use %s or %s", granularity, GranularitySession, GranularityBlock)
+	}
+}
+
+// sessionEvents splits a session into active periods at idle gaps and merge breaks.
+func (e *ICSExporter) sessionEvents(session *models.Session) []calendarEvent {
+	var result []calendarEvent
+	for _, period := range activePeriods(session.Events, e.config.IdleThreshold) {
+		result = append(result, e.newEvent(session, fmt.Sprintf("%s-%d", session.ID, len(result)),
+			period[0].Timestamp, period[len(period)-1].Timestamp, period, ""))
+	}
+	return result
+}
+
+// activePeriods splits events into runs without idle gaps longer than idle, also breaking
+// after session_gap markers. A lone session_gap marker is not a period.
+func activePeriods(events []models.Event, idle time.Duration) [][]models.Event {
+	var periods [][]models.Event
+	start := 0
+	for i := 1; i <= len(events); i++ {
+		if i < len(events) && events[i-1].Type != "session_gap" &&
+			events[i].Timestamp.Sub(events[i-1].Timestamp) <= idle {
+			continue
+		}
+
+		period := events[start:i]
+		start = i
+		if len(period) == 1 && period[0].Type == "session_gap" {
+			continue
+		}
+		periods = append(periods, period)
+	}
+	return periods
+}
+
+// blockEvents merges consecutive activity blocks in the same file that are closer than
+// the idle threshold, one event per run.
+func (e *ICSExporter) blockEvents(session *models.Session) []calendarEvent {
+	blocks, _ := aggregator.New(e.config).AggregateSession(session)
+
+	var result []calendarEvent
+	for i := 0; i < len(blocks); {
+		j := i + 1
+		for j < len(blocks) && blocks[j].Filename == blocks[i].Filename &&
+			blocks[j].StartTime.Sub(blocks[j-1].EndTime) <= e.config.IdleThreshold {
+			j++
+		}
+
+		start, end := blocks[i].StartTime, blocks[j-1].EndTime
+		var period []models.Event
+		for _, ev := range session.Events {
+			if !ev.Timestamp.Before(start) && !ev.Timestamp.After(end) {
+				period = append(period, ev)
+			}
+		}
+
+		result = append(result, e.newEvent(session, fmt.Sprintf("%s-block-%d", session.ID, i),
+			start, end, period, blocks[i].Filename))
+		i = j
+	}
+	return result
+}
+
+// newEvent describes a period of a session. file is set for block events.
+func (e *ICSExporter) newEvent(session *models.Session, id string, start, end time.Time, period []models.Event, file string) calendarEvent {
+	if end.Sub(start) < minCalendarEvent {
+		end = start.Add(minCalendarEvent)
+	}
+
+	project := filepath.Base(session.ProjectPath)
+	summary := project
+	if file != "" {
+		summary += ": " + filepath.Base(file)
+	}
+	if note := firstNote(period); note != "" {
+		summary += " — " + note
+	} else if note := firstNote(session.Events); note != "" && file == "" {
+		summary += " — " + note
+	}
+
+	var desc []string
+	desc = append(desc, "Session: "+session.ID, "Project: "+session.ProjectPath)
+
+	if files := touchedFiles(period, session.ProjectPath); len(files) > 0 {
+		desc = append(desc, "", "Files:")
+		for _, f := range files {
+			desc = append(desc, "- "+f)
+		}
+	}
+	if commits := aggregator.ExtractCommits(period); len(commits) > 0 {
+		desc = append(desc, "", "Commits:")
+		for _, c := range commits {
+			message := strings.SplitN(c.Message, "\n", 2)[0]
+			if message == "" {
+				message = c.Command
+			}
+			desc = append(desc, "- "+message)
+		}
+	}
+	var notes []string
+	for _, ev := range period {
+		if ev.Type == "annotation" && ev.Data.Note != "" {
+			notes = append(notes, "- "+ev.Timestamp.Local().Format("15:04")+" "+ev.Data.Note)
+		}
+	}
+	if len(notes) > 0 {
+		desc = append(desc, "", "Notes:")
+		desc = append(desc, notes...)
+	}
+
+	return calendarEvent{
+		UID:         id + "@capytrace",
+		Start:       start,
+		End:         end,
+		Summary:     summary,
+		Description: strings.Join(desc, "\n"),
+		Categories:  aggregator.ExtractTags(session.Events),
+	}
+}
+
+// firstNote returns the first annotation in events.
+func firstNote(events []models.Event) string {
+	for _, ev := range events {
+		if ev.Type == "annotation" && ev.Data.Note != "" {
+			return ev.Data.Note
+		}
+	}
+	return ""
+}
+
+// touchedFiles lists the files edited in events, relative to the project, sorted.
+func touchedFiles(events []models.Event, projectPath string) []string {
+	seen := make(map[string]bool)
+	var files []string
+	for _, ev := range events {
+		if ev.Type != "file_edit" || ev.Data.Filename == "" {
+			continue
+		}
+		name := aggregator.RelativePath(projectPath, ev.Data.Filename)
+		if !seen[name] {
+			seen[name] = true
+			files = append(files, name)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// icsTime formats a time as a UTC iCalendar DATE-TIME.
+func icsTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// escapeICS escapes a TEXT value (RFC 5545 section 3.3.11).
+func escapeICS(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// foldICS folds a content line at 75 octets without splitting UTF-8 characters.
+func foldICS(s string) string {
+	const limit = 75
+	if len(s) <= limit {
+		return s
+	}
+
+	var sb strings.Builder
+	width := limit
+	for len(s) > width {
+		cut := width
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		sb.WriteString(s[:cut])
+		sb.WriteString("\r\n ")
+		s = s[cut:]
+		width = limit - 1 // Continuation lines start with a space
+	}
+	sb.WriteString(s)
+	return sb.String()
+}
@@ -23,3 +23,8 @@ func (e *JSONExporter) Export(session *models.Session, savePath string) error {
 
 	return os.WriteFile(fullPath, data, 0644)
 }
+
+// Artifacts returns the path of the exported JSON file.
+func (e *JSONExporter) Artifacts(session *models.Session, savePath string) []string {
+	return []string{filepath.Join(savePath, session.ID+"_export.json")}
+}
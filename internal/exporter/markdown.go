@@ -6,9 +6,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"text/template"
 	"time"
 
+	"github.com/andev0x/capytrace.nvim/internal/aggregator"
 	"github.com/andev0x/capytrace.nvim/internal/models"
 )
 
@@ -16,7 +16,11 @@ import (
 var sessionTemplate []byte
 
 // MarkdownExporter exports sessions as human-readable Markdown files.
-type MarkdownExporter struct{}
+type MarkdownExporter struct {
+	// TemplateDir overrides the session's template directory; a session.md found there
+	// replaces the embedded template.
+	TemplateDir string
+}
 
 // Export writes a session to disk as a Markdown file with a detailed timeline and summary.
 func (e *MarkdownExporter) Export(session *models.Session, savePath string) error {
@@ -31,69 +35,29 @@ func (e *MarkdownExporter) Export(session *models.Session, savePath string) erro
 	return os.WriteFile(fullPath, []byte(content), 0644)
 }
 
-type viewData struct {
-	ID               string
-	ProjectPath      string
-	StartDate        string
-	StartTime        string
-	Duration         string
-	FileEdits        int
-	CursorMoves      int
-	TerminalCommands int
-	Annotations      int
-	LSPDiagnostics   int
-	EditPercent      int
-	NavPercent       int
-	TotalEvents      int
-	Blocks           int
-	GroupedEvents    []timelineEvent
+// Artifacts returns the path of the Markdown report.
+func (e *MarkdownExporter) Artifacts(session *models.Session, savePath string) []string {
+	return []string{filepath.Join(savePath, session.ID+".md")}
 }
 
-type timelineEvent struct {
-	Time     string
-	Emoji    string
-	Title    string
-	File     string
-	Location string
-	Snippet  string
-	Details  string
+// TimelineEvent is one row of the grouped timeline shown in Markdown reports and the dashboard.
+// Consecutive edits to the same file are collapsed into a single row.
+type TimelineEvent struct {
+	Type     string    `json:"type"`
+	Start    time.Time `json:"start"` // Timestamp of the first event in the row
+	Time     string    `json:"time"`
+	Emoji    string    `json:"emoji"`
+	Title    string    `json:"title"`
+	File     string    `json:"file,omitempty"`
+	Location string    `json:"location,omitempty"`
+	Snippet  string    `json:"snippet,omitempty"`
+	Details  string    `json:"details,omitempty"`
 }
 
-// generateMarkdown builds the template data and renders the embedded template.
+// generateMarkdown builds the template data and renders the session template.
 func (e *MarkdownExporter) generateMarkdown(session *models.Session) (string, error) {
-	tmpl, err := template.New("session").Parse(string(sessionTemplate))
-	if err != nil {
-		return "", err
-	}
-
-	counts := countEvents(session.Events)
-	editPercent, navPercent := focusRatios(counts["file_edit"], counts["cursor_move"])
-	grouped := groupTimeline(session.Events)
-
-	data := viewData{
-		ID:               session.ID,
-		ProjectPath:      session.ProjectPath,
-		StartDate:        session.StartTime.Format("2006-01-02"),
-		StartTime:        session.StartTime.Format("15:04:05"),
-		Duration:         formatDurationHuman(session.StartTime, session.EndTime),
-		FileEdits:        counts["file_edit"],
-		CursorMoves:      counts["cursor_move"],
-		TerminalCommands: counts["terminal_command"],
-		Annotations:      counts["annotation"],
-		LSPDiagnostics:   counts["lsp_diagnostic"],
-		EditPercent:      editPercent,
-		NavPercent:       navPercent,
-		TotalEvents:      len(session.Events),
-		Blocks:           len(grouped),
-		GroupedEvents:    grouped,
-	}
-
-	var sb strings.Builder
-	if err := tmpl.Execute(&sb, data); err != nil {
-		return "", err
-	}
-
-	return sb.String(), nil
+	data := newTemplateData(session, aggregator.New(aggregator.DefaultConfig()))
+	return renderTemplate(templateDir(e.TemplateDir, session), SessionTemplateName, sessionTemplate, data)
 }
 
 func countEvents(events []models.Event) map[string]int {
@@ -128,9 +92,10 @@ func formatDurationHuman(start, end time.Time) string {
 	return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
 }
 
-func groupTimeline(events []models.Event) []timelineEvent {
-	var grouped []timelineEvent
-	var pending *timelineEvent
+// GroupTimeline collapses raw events into timeline rows, omitting cursor movements.
+func GroupTimeline(events []models.Event) []TimelineEvent {
+	var grouped []TimelineEvent
+	var pending *TimelineEvent
 	var editCount int
 
 	for _, ev := range events {
@@ -150,7 +115,9 @@ func groupTimeline(events []models.Event) []timelineEvent {
 				continue
 			}
 			editCount = 1
-			pending = &timelineEvent{
+			pending = &TimelineEvent{
+				Type:     ev.Type,
+				Start:    ev.Timestamp,
 				Time:     ev.Timestamp.Format("15:04:05"),
 				Emoji:    "🛠",
 				Title:    "Edits",
@@ -164,21 +131,24 @@ func groupTimeline(events []models.Event) []timelineEvent {
 		}
 
 		pending = nil
-		grouped = append(grouped, timelineEvent{
+		grouped = append(grouped, TimelineEvent{
+			Type:     ev.Type,
+			Start:    ev.Timestamp,
 			Time:     ev.Timestamp.Format("15:04:05"),
-			Emoji:    emojiFor(ev.Type),
+			Emoji:    EmojiFor(ev.Type),
 			Title:    titleFor(ev),
 			File:     ev.Data.Filename,
 			Location: locationFor(ev),
 			Snippet:  trimSnippet(ev.Data.LineText),
-			Details:  detailFor(ev),
+			Details:  DetailFor(ev),
 		})
 	}
 
 	return grouped
 }
 
-func emojiFor(eventType string) string {
+// EmojiFor returns the emoji shown for an event type in timelines.
+func EmojiFor(eventType string) string {
 	switch eventType {
 	case "annotation":
 		return "📝"
@@ -196,6 +166,8 @@ func emojiFor(eventType string) string {
 		return "🏁"
 	case "session_resume":
 		return "🔄"
+	case "session_gap":
+		return "⏸"
 	default:
 		return "•"
 	}
@@ -219,6 +191,8 @@ func titleFor(ev models.Event) string {
 		return "Session Ended"
 	case "session_resume":
 		return "Session Resumed"
+	case "session_gap":
+		return "Session Gap"
 	default:
 		return strings.Title(strings.ReplaceAll(ev.Type, "_", " "))
 	}
@@ -242,9 +216,10 @@ func trimSnippet(s string) string {
 	return s
 }
 
-func detailFor(ev models.Event) string {
+// DetailFor returns the note, message or command that describes an event, or "".
+func DetailFor(ev models.Event) string {
 	switch ev.Type {
-	case "annotation":
+	case "annotation", "session_gap":
 		return ev.Data.Note
 	case "lsp_diagnostic":
 		return ev.Data.Message
@@ -0,0 +1,246 @@
+package exporter
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/aggregator"
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+// OptionSummaryMermaid is the session export option ("true") that adds Mermaid diagrams
+// to SESSION_SUMMARY.md.
+const OptionSummaryMermaid = "summary.mermaid"
+
+// Size limits that keep the diagrams readable and within what GitHub and editor
+// previewers render for long sessions.
+const (
+	mermaidMaxGanttTasks = 40 // Longest activity blocks kept in the Gantt chart
+	mermaidMaxPieSlices  = 8  // Files by time; the rest become one "other" slice
+	mermaidMaxFlowNodes  = 12 // Files in the transition flowchart
+	mermaidMaxFlowEdges  = 24 // Most frequent transitions between those files
+)
+
+// MermaidDiagrams holds the bodies of the Mermaid blocks for the summary. A body is empty
+// when there is nothing to draw; the Omitted counts say what the limits left out.
+type MermaidDiagrams struct {
+	Gantt        string // Activity blocks per file over time, flow blocks marked crit
+	GanttOmitted int    // Shorter blocks left out
+
+	Pie        string // Time per file from Analytics.MainFiles
+	PieOmitted int    // Files folded into the "other" slice
+
+	Flow        string // File-to-file transitions between consecutive activity blocks
+	FlowOmitted int    // Transitions left out by the node or edge limit
+}
+
+// mermaidEnabled reports whether the session's export options ask for Mermaid diagrams.
+func mermaidEnabled(session *models.Session) bool {
+	switch strings.ToLower(session.ExportOptions[OptionSummaryMermaid]) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
+// buildMermaid draws the summary diagrams from a session's view model.
+func buildMermaid(data *TemplateData) *MermaidDiagrams {
+	var files []string
+	for _, block := range data.ActivityBlocks {
+		files = append(files, block.Filename)
+	}
+	for _, f := range data.Files {
+		files = append(files, f.File)
+	}
+	names := mermaidFileNames(files, data.ProjectPath)
+
+	m := &MermaidDiagrams{}
+	m.Gantt, m.GanttOmitted = mermaidGantt(data, names)
+	m.Pie, m.PieOmitted = mermaidPie(data, names)
+	m.Flow, m.FlowOmitted = mermaidFlow(data, names)
+	return m
+}
+
+// mermaidGantt draws the longest activity blocks, one section per file in order of
+// first activity.
+func mermaidGantt(data *TemplateData, names map[string]string) (string, int) {
+	if len(data.ActivityBlocks) == 0 {
+		return "", 0
+	}
+
+	blocks := append([]models.ActivityBlock(nil), data.ActivityBlocks...)
+	omitted := 0
+	if len(blocks) > mermaidMaxGanttTasks {
+		sort.SliceStable(blocks, func(i, j int) bool { return blocks[i].Duration > blocks[j].Duration })
+		omitted = len(blocks) - mermaidMaxGanttTasks
+		blocks = blocks[:mermaidMaxGanttTasks]
+	}
+	sort.SliceStable(blocks, func(i, j int) bool { return blocks[i].StartTime.Before(blocks[j].StartTime) })
+
+	flow := make(map[time.Time]bool)
+	for _, block := range data.Analytics.FlowBlocks {
+		flow[block.StartTime] = true
+	}
+
+	var files []string
+	byFile := make(map[string][]models.ActivityBlock)
+	for _, block := range blocks {
+		if byFile[block.Filename] == nil {
+			files = append(files, block.Filename)
+		}
+		byFile[block.Filename] = append(byFile[block.Filename], block)
+	}
+
+	const layout = "2006-01-02 15:04:05"
+	var sb strings.Builder
+	sb.WriteString("gantt\n")
+	sb.WriteString("    title Activity blocks\n")
+	sb.WriteString("    dateFormat YYYY-MM-DD HH:mm:ss\n")
+	sb.WriteString("    axisFormat %H:%M\n")
+	sb.WriteString("    todayMarker off\n")
+	for _, file := range files {
+		fmt.Fprintf(&sb, "    section %s\n", mermaidLabel(names[file]))
+		for _, block := range byFile[file] {
+			end := block.EndTime
+			if end.Sub(block.StartTime) < time.Second {
+				end = block.StartTime.Add(time.Second) // Zero-length tasks are not drawn
+			}
+			status := "done"
+			if flow[block.StartTime] {
+				status = "crit"
+			}
+			fmt.Fprintf(&sb, "    %d edits :%s, %s, %s\n", block.EventCount, status,
+				block.StartTime.Local().Format(layout), end.Local().Format(layout))
+		}
+	}
+	return sb.String(), omitted
+}
+
+// mermaidPie draws time per file in minutes, folding small files into "other".
+func mermaidPie(data *TemplateData, names map[string]string) (string, int) {
+	if len(data.Files) == 0 {
+		return "", 0
+	}
+
+	files := data.Files
+	var other time.Duration
+	omitted := 0
+	if len(files) > mermaidMaxPieSlices {
+		for _, f := range files[mermaidMaxPieSlices-1:] {
+			other += f.Time
+		}
+		omitted = len(files) - (mermaidMaxPieSlices - 1)
+		files = files[:mermaidMaxPieSlices-1]
+	}
+
+	var sb strings.Builder
+	sb.WriteString("pie showData\n")
+	sb.WriteString("    title Minutes per file\n")
+	for _, f := range files {
+		fmt.Fprintf(&sb, "    \"%s\" : %.1f\n", mermaidLabel(names[f.File]), f.Time.Minutes())
+	}
+	if omitted > 0 {
+		fmt.Fprintf(&sb, "    \"%d other files\" : %.1f\n", omitted, other.Minutes())
+	}
+	return sb.String(), omitted
+}
+
+// mermaidFlow draws how often work moved from one file to another between consecutive
+// activity blocks. Edges are taken busiest first while their files fit the node limit.
+func mermaidFlow(data *TemplateData, names map[string]string) (string, int) {
+	type edge struct{ from, to string }
+	counts := make(map[edge]int)
+	total := 0
+	for i := 1; i < len(data.ActivityBlocks); i++ {
+		from, to := data.ActivityBlocks[i-1].Filename, data.ActivityBlocks[i].Filename
+		if from != to {
+			counts[edge{from, to}]++
+			total++
+		}
+	}
+	if len(counts) == 0 {
+		return "", 0
+	}
+
+	var ranked []edge
+	for e := range counts {
+		ranked = append(ranked, e)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if counts[ranked[i]] != counts[ranked[j]] {
+			return counts[ranked[i]] > counts[ranked[j]]
+		}
+		if ranked[i].from != ranked[j].from {
+			return ranked[i].from < ranked[j].from
+		}
+		return ranked[i].to < ranked[j].to
+	})
+
+	ids := make(map[string]string)
+	var nodes []string
+	var edges []edge
+	shown := 0
+	for _, e := range ranked {
+		if len(edges) == mermaidMaxFlowEdges {
+			break
+		}
+		added := 0
+		for _, file := range []string{e.from, e.to} {
+			if ids[file] == "" {
+				added++
+			}
+		}
+		if len(nodes)+added > mermaidMaxFlowNodes {
+			continue
+		}
+		for _, file := range []string{e.from, e.to} {
+			if ids[file] == "" {
+				ids[file] = fmt.Sprintf("f%d", len(nodes))
+				nodes = append(nodes, file)
+			}
+		}
+		edges = append(edges, e)
+		shown += counts[e]
+	}
+
+	var sb strings.Builder
+	sb.WriteString("flowchart LR\n")
+	for _, file := range nodes {
+		fmt.Fprintf(&sb, "    %s[\"%s\"]\n", ids[file], mermaidLabel(names[file]))
+	}
+	for _, e := range edges {
+		fmt.Fprintf(&sb, "    %s -->|%d| %s\n", ids[e.from], counts[e], ids[e.to])
+	}
+	return sb.String(), total - shown
+}
+
+// mermaidFileNames labels files by base name, falling back to the project-relative path
+// when two files share a base name.
+func mermaidFileNames(files []string, projectPath string) map[string]string {
+	bases := make(map[string]int)
+	seen := make(map[string]bool)
+	for _, file := range files {
+		if !seen[file] {
+			seen[file] = true
+			bases[filepath.Base(file)]++
+		}
+	}
+	names := make(map[string]string, len(files))
+	for _, file := range files {
+		if bases[filepath.Base(file)] > 1 {
+			names[file] = aggregator.RelativePath(projectPath, file)
+		} else {
+			names[file] = filepath.Base(file)
+		}
+	}
+	return names
+}
+
+// mermaidLabel removes the characters that end a Mermaid label or task name early.
+func mermaidLabel(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	return strings.NewReplacer(`"`, "'", ":", "꞉", ";", ",", "#", "♯", "|", "¦").Replace(s)
+}
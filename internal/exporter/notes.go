@@ -0,0 +1,579 @@
+package exporter
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/andev0x/capytrace.nvim/internal/aggregator"
+	"github.com/andev0x/capytrace.nvim/internal/models"
+	"github.com/andev0x/capytrace.nvim/internal/report"
+)
+
+//go:embed templates/notes.md
+var notesTemplate []byte
+
+// NotesTemplateName is the template for a session's section of a daily note.
+const NotesTemplateName = "notes.md"
+
+// Session export options of the notes exporter.
+const (
+	// OptionNotesDir is the vault directory daily notes are written to; defaults to the
+	// notes subdirectory of the save path, away from the session files.
+	OptionNotesDir = "notes.dir"
+	// OptionNotesPath is the daily-note path pattern inside the vault, see DefaultNotesPath.
+	OptionNotesPath = "notes.path"
+)
+
+// DefaultNotesPath names one note per day. Patterns may use {date} (2006-01-02), {year},
+// {month}, {day}, {week} (ISO week number) and {project}, e.g. "Journal/{year}/{date}.md".
+const DefaultNotesPath = "{date}.md"
+
+// notesFrontMatterKey is the front-matter key holding one entry per exported session.
+const notesFrontMatterKey = "capytrace"
+
+// Daily notes are shared by every session of a day, so updates take a lock file next to
+// the note. A lock older than notesLockStale was left by a crashed export and is broken.
+const (
+	notesLockWait  = 5 * time.Second
+	notesLockStale = 30 * time.Second
+)
+
+// NotesExporter adds a section per session to a dated daily note in a Markdown vault such
+// as Obsidian, with YAML front-matter listing the day's sessions. Re-exporting a session
+// replaces its section and front-matter entry, so the periodic summary can run it safely.
+type NotesExporter struct {
+	aggregator *aggregator.Aggregator
+
+	Dir         string // Vault directory; defaults to the session's notes.dir option, then savePath/notes
+	PathPattern string // Daily-note path pattern; defaults to the session's notes.path option
+
+	// TemplateDir overrides the session's template directory; a notes.md found there
+	// replaces the embedded template.
+	TemplateDir string
+}
+
+// NewNotesExporter creates a daily-notes exporter with the given aggregation rules.
+func NewNotesExporter(config *aggregator.AggregatorConfig) *NotesExporter {
+	return &NotesExporter{aggregator: aggregator.New(config)}
+}
+
+// NotesData is the view model passed to notes.md.
+type NotesData struct {
+	*TemplateData
+
+	FlowTime time.Duration
+	Tags     []string
+	Links    []NoteFile // Edited and visited files, longest first
+	Commits  []aggregator.Commit
+}
+
+// NoteFile is a file shown as a wiki-link in a daily note.
+type NoteFile struct {
+	Path string // Relative to the project when inside it
+	Link string // [[path|name]]
+	Time time.Duration
+}
+
+// notesEntry is one session in the daily note's front-matter.
+type notesEntry struct {
+	ID              string
+	Project         string
+	Start           string // RFC 3339, used to order entries
+	Duration        string
+	DurationMinutes int
+	FlowMinutes     int
+	Tags            []string
+}
+
+// Export writes or updates the session's section of its daily note.
+func (e *NotesExporter) Export(session *models.Session, savePath string) error {
+	path := e.notePath(session, savePath)
+	data := e.notesData(session)
+
+	section, err := renderTemplate(templateDir(e.TemplateDir, session), NotesTemplateName, notesTemplate, data)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	unlock, err := lockNote(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	// An active session counts up to its latest event, so periodic exports show progress
+	elapsed := data.Elapsed
+	if !data.Ended && len(session.Events) > 0 {
+		elapsed = session.Events[len(session.Events)-1].Timestamp.Sub(session.StartTime)
+	}
+
+	entry := notesEntry{
+		ID:              session.ID,
+		Project:         filepath.Base(session.ProjectPath),
+		Start:           session.StartTime.Format(time.RFC3339),
+		Duration:        data.Duration,
+		DurationMinutes: int(elapsed.Round(time.Minute).Minutes()),
+		FlowMinutes:     int(data.FlowTime.Round(time.Minute).Minutes()),
+		Tags:            data.Tags,
+	}
+	content := updateDailyNote(string(existing), session.StartTime, entry, section)
+
+	return writeNote(path, []byte(content))
+}
+
+// Remove deletes the session's section and front-matter entry from its daily note, e.g.
+// before the session is renamed. A missing note is not an error.
+func (e *NotesExporter) Remove(session *models.Session, savePath string) error {
+	path := e.notePath(session, savePath)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	unlock, err := lockNote(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	existing, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return writeNote(path, []byte(removeFromDailyNote(string(existing), session.ID)))
+}
+
+// lockNote takes the lock file of a daily note, waiting for another export to finish.
+// The returned function releases it.
+func lockNote(path string) (func(), error) {
+	lock := path + ".lock"
+	deadline := time.Now().Add(notesLockWait)
+	for {
+		f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() { _ = os.Remove(lock) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if info, statErr := os.Stat(lock); statErr == nil && time.Since(info.ModTime()) > notesLockStale {
+			_ = os.Remove(lock)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("daily note %s is locked by another export (%s)", path, lock)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// writeNote replaces a daily note through a temporary file in the same directory, so
+// readers such as a vault app never see a partly written note.
+func writeNote(path string, content []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Artifacts returns the path of the daily note.
+func (e *NotesExporter) Artifacts(session *models.Session, savePath string) []string {
+	return []string{e.notePath(session, savePath)}
+}
+
+// notePath expands the path pattern for the day the session started.
+func (e *NotesExporter) notePath(session *models.Session, savePath string) string {
+	dir := e.Dir
+	if dir == "" {
+		dir = session.ExportOptions[OptionNotesDir]
+	}
+	if dir == "" {
+		dir = filepath.Join(savePath, "notes")
+	}
+	pattern := e.PathPattern
+	if pattern == "" {
+		pattern = session.ExportOptions[OptionNotesPath]
+	}
+	if pattern == "" {
+		pattern = DefaultNotesPath
+	}
+
+	day := session.StartTime
+	_, week := day.ISOWeek()
+	name := strings.NewReplacer(
+		"{date}", day.Format("2006-01-02"),
+		"{year}", day.Format("2006"),
+		"{month}", day.Format("01"),
+		"{day}", day.Format("02"),
+		"{week}", fmt.Sprintf("%02d", week),
+		"{project}", filepath.Base(session.ProjectPath),
+	).Replace(pattern)
+	return filepath.Join(dir, filepath.FromSlash(name))
+}
+
+// notesData builds the view model for a session's section.
+func (e *NotesExporter) notesData(session *models.Session) *NotesData {
+	data := &NotesData{
+		TemplateData: newTemplateData(session, e.aggregator),
+		Tags:         aggregator.ExtractTags(session.Events),
+		Commits:      aggregator.ExtractCommits(session.Events),
+	}
+	data.FlowTime = report.FlowTime(data.Analytics)
+
+	for _, share := range data.Files {
+		path := filepath.ToSlash(aggregator.RelativePath(session.ProjectPath, share.File))
+		data.Links = append(data.Links, NoteFile{Path: path, Link: wikiLink(path), Time: share.Time})
+	}
+	return data
+}
+
+// wikiLink returns an Obsidian-style link to a file, showing only its base name.
+func wikiLink(path string) string {
+	base := filepath.Base(path)
+	if base == path {
+		return "[[" + path + "]]"
+	}
+	return "[[" + path + "|" + base + "]]"
+}
+
+// sectionMarkers return the comments that delimit a session's section in a daily note.
+func sectionMarkers(sessionID string) (string, string) {
+	return "<!-- capytrace:" + sessionID + " -->", "<!-- /capytrace:" + sessionID + " -->"
+}
+
+// updateDailyNote replaces or appends the session's section and front-matter entry,
+// leaving everything else in the note untouched.
+func updateDailyNote(content string, day time.Time, entry notesEntry, section string) string {
+	frontMatter, body := splitFrontMatter(content)
+
+	begin, end := sectionMarkers(entry.ID)
+	block := begin + "\n" + strings.TrimRight(section, "\n") + "\n" + end
+	if i := strings.Index(body, begin); i >= 0 {
+		if j := strings.Index(body[i:], end); j >= 0 {
+			body = body[:i] + block + body[i+j+len(end):]
+		} else {
+			body = body[:i] + block + "\n"
+		}
+	} else {
+		body = strings.TrimRight(body, "\n")
+		if body != "" {
+			body += "\n\n"
+		}
+		body += block + "\n"
+	}
+
+	return "---\n" + updateFrontMatter(frontMatter, day, entry) + "---\n" + body
+}
+
+// removeFromDailyNote drops a session's section and front-matter entry, leaving
+// everything else in the note untouched.
+func removeFromDailyNote(content, sessionID string) string {
+	frontMatter, body := splitFrontMatter(content)
+
+	begin, end := sectionMarkers(sessionID)
+	if i := strings.Index(body, begin); i >= 0 {
+		rest := ""
+		if j := strings.Index(body[i:], end); j >= 0 {
+			rest = strings.TrimLeft(body[i+j+len(end):], "\n")
+		}
+		before := strings.TrimRight(body[:i], "\n")
+		switch {
+		case before != "" && rest != "":
+			body = before + "\n\n" + rest
+		case before != "":
+			body = before + "\n"
+		default:
+			body = rest
+		}
+	}
+
+	keys, values := splitYAMLKeys(frontMatter)
+	if _, ok := values[notesFrontMatterKey]; !ok {
+		if frontMatter == "" {
+			return body
+		}
+		return "---\n" + frontMatter + "---\n" + body
+	}
+
+	var kept []notesEntry
+	for _, entry := range parseNotesEntries(values[notesFrontMatterKey]) {
+		if entry.ID != sessionID {
+			kept = append(kept, entry)
+		}
+	}
+	var sb strings.Builder
+	for _, key := range keys {
+		if key == notesFrontMatterKey {
+			writeNotesEntries(&sb, kept)
+			continue
+		}
+		for _, line := range values[key] {
+			sb.WriteString(line + "\n")
+		}
+	}
+	return "---\n" + sb.String() + "---\n" + body
+}
+
+// splitFrontMatter separates a leading YAML front-matter block (without its --- fences)
+// from the rest of a note.
+func splitFrontMatter(content string) (string, string) {
+	if !strings.HasPrefix(content, "---\n") {
+		return "", content
+	}
+	rest := content[len("---\n"):]
+	if strings.HasPrefix(rest, "---\n") {
+		return "", rest[len("---\n"):]
+	}
+	i := strings.Index(rest, "\n---\n")
+	if i < 0 {
+		if strings.HasSuffix(rest, "\n---") {
+			return rest[:len(rest)-len("---")], ""
+		}
+		return "", content
+	}
+	return rest[:i+1], rest[i+len("\n---\n"):]
+}
+
+// updateFrontMatter sets the session's entry under the capytrace key, adds its tags to the
+// note's tags, and adds a date when there is none. Other keys are kept as written.
+func updateFrontMatter(frontMatter string, day time.Time, entry notesEntry) string {
+	keys, values := splitYAMLKeys(frontMatter)
+
+	entries := parseNotesEntries(values[notesFrontMatterKey])
+	replaced := false
+	for i := range entries {
+		if entries[i].ID == entry.ID {
+			entries[i] = entry
+			replaced = true
+		}
+	}
+	if !replaced {
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Start < entries[j].Start })
+
+	tags, tagsParsed := parseYAMLList(values["tags"])
+	if tagsParsed {
+		for _, tag := range append([]string{"capytrace"}, entry.Tags...) {
+			if !containsString(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	if _, ok := values["date"]; !ok {
+		keys = append([]string{"date"}, keys...)
+		values["date"] = []string{"date: " + day.Format("2006-01-02")}
+	}
+	if _, ok := values["tags"]; !ok {
+		keys = append(keys, "tags")
+	}
+	if _, ok := values[notesFrontMatterKey]; !ok {
+		keys = append(keys, notesFrontMatterKey)
+	}
+
+	var sb strings.Builder
+	for _, key := range keys {
+		switch {
+		case key == "tags" && tagsParsed:
+			sb.WriteString("tags:\n")
+			for _, tag := range tags {
+				sb.WriteString("  - " + yamlString(tag) + "\n")
+			}
+		case key == notesFrontMatterKey:
+			writeNotesEntries(&sb, entries)
+		default:
+			for _, line := range values[key] {
+				sb.WriteString(line + "\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// splitYAMLKeys groups front-matter lines by top-level key, in order. Lines before the first
+// key (comments, blank lines) are kept under the empty key.
+func splitYAMLKeys(frontMatter string) ([]string, map[string][]string) {
+	var keys []string
+	values := make(map[string][]string)
+	current := ""
+
+	for _, line := range strings.Split(strings.TrimSuffix(frontMatter, "\n"), "\n") {
+		if frontMatter == "" {
+			break
+		}
+		if line != "" && line[0] != ' ' && line[0] != '-' && line[0] != '#' {
+			if key, _, ok := strings.Cut(line, ":"); ok {
+				current = strings.TrimSpace(key)
+			}
+		}
+		if _, ok := values[current]; !ok {
+			keys = append(keys, current)
+		}
+		values[current] = append(values[current], line)
+	}
+	return keys, values
+}
+
+// parseYAMLList reads a key's value as a list written inline ([a, b] or a single scalar)
+// or as "- item" lines. An absent key is an empty list; other shapes are not parsed.
+func parseYAMLList(lines []string) ([]string, bool) {
+	if len(lines) == 0 {
+		return nil, true
+	}
+	_, inline, _ := strings.Cut(lines[0], ":")
+	inline = strings.TrimSpace(inline)
+
+	var items []string
+	switch {
+	case strings.HasPrefix(inline, "[") && strings.HasSuffix(inline, "]"):
+		for _, item := range strings.Split(inline[1:len(inline)-1], ",") {
+			if item = yamlUnquote(strings.TrimSpace(item)); item != "" {
+				items = append(items, item)
+			}
+		}
+	case inline != "":
+		items = append(items, yamlUnquote(inline))
+	}
+
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, "- "):
+			items = append(items, yamlUnquote(strings.TrimSpace(trimmed[2:])))
+		default:
+			return nil, false
+		}
+	}
+	return items, true
+}
+
+// parseNotesEntries reads the entries previously written by writeNotesEntries.
+func parseNotesEntries(lines []string) []notesEntry {
+	var entries []notesEntry
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "- ") {
+			entries = append(entries, notesEntry{})
+			trimmed = strings.TrimSpace(trimmed[2:])
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		e := &entries[len(entries)-1]
+		switch strings.TrimSpace(key) {
+		case "id":
+			e.ID = yamlUnquote(value)
+		case "project":
+			e.Project = yamlUnquote(value)
+		case "start":
+			e.Start = yamlUnquote(value)
+		case "duration":
+			e.Duration = yamlUnquote(value)
+		case "duration_minutes":
+			e.DurationMinutes, _ = strconv.Atoi(value)
+		case "flow_minutes":
+			e.FlowMinutes, _ = strconv.Atoi(value)
+		case "tags":
+			e.Tags, _ = parseYAMLList([]string{trimmed})
+		}
+	}
+	return entries
+}
+
+// writeNotesEntries writes the capytrace front-matter key.
+func writeNotesEntries(sb *strings.Builder, entries []notesEntry) {
+	sb.WriteString(notesFrontMatterKey + ":\n")
+	for _, e := range entries {
+		tags := make([]string, len(e.Tags))
+		for i, tag := range e.Tags {
+			tags[i] = yamlString(tag)
+		}
+		fmt.Fprintf(sb, "  - id: %s\n", yamlString(e.ID))
+		fmt.Fprintf(sb, "    project: %s\n", yamlString(e.Project))
+		fmt.Fprintf(sb, "    start: %s\n", yamlString(e.Start))
+		fmt.Fprintf(sb, "    duration: %s\n", yamlString(e.Duration))
+		fmt.Fprintf(sb, "    duration_minutes: %d\n", e.DurationMinutes)
+		fmt.Fprintf(sb, "    flow_minutes: %d\n", e.FlowMinutes)
+		fmt.Fprintf(sb, "    tags: [%s]\n", strings.Join(tags, ", "))
+	}
+}
+
+// yamlString writes a scalar plainly when it is a simple word, else double-quoted.
+// Words YAML would read as numbers or booleans are quoted too.
+func yamlString(s string) string {
+	plain := s != "" && !strings.HasPrefix(s, "-")
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			plain = false
+			break
+		}
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		plain = false
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "null", "y", "n":
+		plain = false
+	}
+	if plain {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+// yamlUnquote strips YAML single or double quotes from a scalar.
+func yamlUnquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if unquoted, err := strconv.Unquote(s); err == nil {
+			return unquoted
+		}
+	}
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	return s
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
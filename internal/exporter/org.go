@@ -0,0 +1,234 @@
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/aggregator"
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+// OrgExporter exports sessions as Org documents ({session_id}.org) for Emacs users.
+// The session heading carries a CLOCK line per active period, split at idle gaps like
+// the iCalendar export, so org-agenda clock tables count working time only. Activity
+// blocks are child headings without clocks, so subtree totals are not counted twice.
+type OrgExporter struct {
+	config *aggregator.AggregatorConfig
+}
+
+// NewOrgExporter creates an Org exporter.
+func NewOrgExporter(config *aggregator.AggregatorConfig) *OrgExporter {
+	return &OrgExporter{config: config}
+}
+
+// Export writes the session to {session_id}.org in savePath.
+func (e *OrgExporter) Export(session *models.Session, savePath string) error {
+	file, err := os.Create(filepath.Join(savePath, session.ID+".org"))
+	if err != nil {
+		return err
+	}
+	if err := e.Write(file, session); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Artifacts returns the path of the Org document.
+func (e *OrgExporter) Artifacts(session *models.Session, savePath string) []string {
+	return []string{filepath.Join(savePath, session.ID+".org")}
+}
+
+// Write renders one session as an Org document.
+func (e *OrgExporter) Write(w io.Writer, session *models.Session) error {
+	agg := aggregator.New(e.config)
+	blocks, analytics := agg.AggregateSession(session)
+	project := filepath.Base(session.ProjectPath)
+
+	bw := bufio.NewWriter(w)
+	line := func(format string, args ...interface{}) {
+		fmt.Fprintf(bw, format, args...)
+		bw.WriteString("\n")
+	}
+
+	line("#+TITLE: %s — %s", orgText(session.ID), orgText(project))
+	line("#+DATE: %s", orgTimestamp(session.StartTime))
+	line("#+STARTUP: overview")
+	line("")
+
+	line("* %s%s", orgText(session.ID+" — "+project), orgTags(aggregator.ExtractTags(session.Events)))
+	line(":PROPERTIES:")
+	line(":SESSION_ID: %s", session.ID)
+	line(":PROJECT:    %s", session.ProjectPath)
+	line(":STARTED:    %s", orgTimestamp(session.StartTime))
+	if !session.EndTime.IsZero() {
+		line(":ENDED:      %s", orgTimestamp(session.EndTime))
+		line(":DURATION:   %s", formatDuration(session.EndTime.Sub(session.StartTime)))
+	} else {
+		line(":STATUS:     active")
+	}
+	line(":EVENTS:     %d", len(session.Events))
+	line(":BLOCKS:     %d", len(blocks))
+	line(":IDLE:       %s", formatDuration(analytics.TotalIdleTime))
+	line(":FOCUS:      %.1f%%", analytics.FocusRatio*100)
+	line(":END:")
+
+	periods := activePeriods(session.Events, e.config.IdleThreshold)
+	if len(periods) > 0 {
+		line(":LOGBOOK:")
+		// Org lists the most recent clock first
+		for i := len(periods) - 1; i >= 0; i-- {
+			period := periods[i]
+			line("%s", orgClock(period[0].Timestamp, period[len(period)-1].Timestamp))
+		}
+		line(":END:")
+	}
+
+	e.writeNotes(line, session.Events)
+	e.writeDiagnostics(line, session)
+	e.writeCommands(line, session.Events)
+	e.writeBlocks(line, blocks, session.ProjectPath)
+
+	return bw.Flush()
+}
+
+// writeNotes lists annotations with their timestamps.
+func (e *OrgExporter) writeNotes(line func(string, ...interface{}), events []models.Event) {
+	var notes []models.Event
+	for _, event := range events {
+		if event.Type == "annotation" && strings.TrimSpace(event.Data.Note) != "" {
+			notes = append(notes, event)
+		}
+	}
+	if len(notes) == 0 {
+		return
+	}
+
+	line("** Notes")
+	for _, note := range notes {
+		line("- %s %s", orgTimestamp(note.Timestamp), orgText(note.Data.Note))
+	}
+}
+
+// writeDiagnostics writes LSP diagnostics as an Org table.
+func (e *OrgExporter) writeDiagnostics(line func(string, ...interface{}), session *models.Session) {
+	var rows [][]string
+	for _, event := range session.Events {
+		if event.Type != "lsp_diagnostic" {
+			continue
+		}
+		rows = append(rows, []string{
+			event.Timestamp.Format("15:04:05"),
+			aggregator.RelativePath(session.ProjectPath, event.Data.Filename),
+			fmt.Sprint(event.Data.Line),
+			event.Data.Level,
+			event.Data.Message,
+		})
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	line("** Diagnostics")
+	line("| Time | File | Line | Level | Message |")
+	line("|------+------+------+-------+---------|")
+	for _, row := range rows {
+		for i, cell := range row {
+			row[i] = orgCell(cell)
+		}
+		line("| %s |", strings.Join(row, " | "))
+	}
+}
+
+// writeCommands lists terminal commands with their exit codes.
+func (e *OrgExporter) writeCommands(line func(string, ...interface{}), events []models.Event) {
+	first := true
+	for _, event := range events {
+		if event.Type != "terminal_command" {
+			continue
+		}
+		if first {
+			line("** Commands")
+			first = false
+		}
+		status := ""
+		if event.Data.ExitCode != nil {
+			status = fmt.Sprintf(" (exit %d)", *event.Data.ExitCode)
+		}
+		line("- %s ~%s~%s", orgTimestamp(event.Timestamp), orgText(event.Data.Command), status)
+	}
+}
+
+// writeBlocks writes a heading with a properties drawer per activity block.
+func (e *OrgExporter) writeBlocks(line func(string, ...interface{}), blocks []models.ActivityBlock, projectPath string) {
+	if len(blocks) == 0 {
+		return
+	}
+
+	line("** Activity")
+	for _, block := range blocks {
+		flow := ""
+		if block.Velocity >= e.config.FlowVelocityThreshold {
+			flow = " :flow:"
+		}
+		line("*** %s %s%s", block.StartTime.Format("15:04:05"), orgText(filepath.Base(block.Filename)), flow)
+		line(":PROPERTIES:")
+		line(":FILE:      %s", aggregator.RelativePath(projectPath, block.Filename))
+		line(":START:     %s", orgTimestamp(block.StartTime))
+		line(":DURATION:  %s", formatDuration(block.Duration))
+		line(":EDITS:     %d", block.EventCount)
+		line(":TICKS:     %d", block.DeltaTick)
+		line(":VELOCITY:  %.2f", block.Velocity)
+		line(":CLOSED_BY: %s", block.ClosedBy)
+		line(":END:")
+	}
+}
+
+// orgTimestamp formats an inactive Org timestamp such as [2025-01-31 Fri 14:05].
+func orgTimestamp(t time.Time) string {
+	return t.Local().Format("[2006-01-02 Mon 15:04]")
+}
+
+// orgClock formats a CLOCK line. Org clocks have minute resolution, so the interval is
+// widened to whole minutes (start rounded down, end rounded up) and lasts at least one minute.
+func orgClock(start, end time.Time) string {
+	start = start.Truncate(time.Minute)
+	if rounded := end.Truncate(time.Minute); !rounded.Equal(end) {
+		end = rounded.Add(time.Minute)
+	}
+	if !end.After(start) {
+		end = start.Add(time.Minute)
+	}
+	d := end.Sub(start)
+	return fmt.Sprintf("CLOCK: %s--%s => %2d:%02d", orgTimestamp(start), orgTimestamp(end), int(d.Hours()), int(d.Minutes())%60)
+}
+
+// orgTags formats tags as a heading tag list such as " :capytrace:bug:". Org tags may only
+// contain letters, digits, _, @, # and %, so other characters become _.
+func orgTags(tags []string) string {
+	all := append([]string{"capytrace"}, tags...)
+	for i, tag := range all {
+		all[i] = strings.Map(func(r rune) rune {
+			if r == '_' || r == '@' || r == '#' || r == '%' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+				return r
+			}
+			return '_'
+		}, tag)
+	}
+	return " :" + strings.Join(all, ":") + ":"
+}
+
+// orgText flattens text onto one line so it cannot start a heading or drawer.
+func orgText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// orgCell makes text safe inside an Org table cell.
+func orgCell(s string) string {
+	return strings.ReplaceAll(orgText(s), "|", `\vert{}`)
+}
@@ -0,0 +1,204 @@
+package exporter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+// PluginPrefix is the executable name prefix of external exporters: an executable named
+// capytrace-export-<name> on PATH provides the output format <name>.
+const PluginPrefix = "capytrace-export-"
+
+// Plugin time limits.
+const (
+	manifestTimeout = 10 * time.Second
+	pluginTimeout   = 5 * time.Minute
+)
+
+// PluginExporter runs an external exporter executable.
+//
+// Protocol:
+//   - `capytrace-export-<name> --manifest` prints the plugin's Manifest as JSON.
+//   - `capytrace-export-<name> --output <path>` receives the session JSON on stdin and writes
+//     its artifact, normally to <path> ({save_path}/{session_id}{extension}). It may print the
+//     paths it wrote, one per line; otherwise <path> is reported when it exists.
+//   - The environment carries CAPYTRACE_SESSION_ID, CAPYTRACE_SAVE_PATH, CAPYTRACE_OUTPUT and
+//     CAPYTRACE_OPTION_<NAME> for each option in the manifest.
+//   - A non-zero exit fails the export; stderr becomes the error message.
+type PluginExporter struct {
+	Path     string
+	Manifest Manifest
+
+	written []string
+}
+
+// Export pipes the session to the plugin.
+func (e *PluginExporter) Export(session *models.Session, savePath string) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	output := e.output(session, savePath)
+	ctx, cancel := context.WithTimeout(context.Background(), pluginTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, e.Path, "--output", output)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(),
+		"CAPYTRACE_SESSION_ID="+session.ID,
+		"CAPYTRACE_SAVE_PATH="+savePath,
+		"CAPYTRACE_OUTPUT="+output,
+	)
+	for _, option := range e.Manifest.Options {
+		value, ok := session.ExportOptions[option.Name]
+		if !ok {
+			value = option.Default
+		}
+		cmd.Env = append(cmd.Env, optionEnv(option.Name)+"="+value)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("plugin %s: %w: %s", e.Path, err, msg)
+		}
+		return fmt.Errorf("plugin %s: %w", e.Path, err)
+	}
+
+	e.written = nil
+	scanner := bufio.NewScanner(&stdout)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			e.written = append(e.written, line)
+		}
+	}
+	return nil
+}
+
+// Artifacts returns the paths the plugin reported, or its output path when it exists.
+func (e *PluginExporter) Artifacts(session *models.Session, savePath string) []string {
+	if len(e.written) > 0 {
+		return e.written
+	}
+	output := e.output(session, savePath)
+	if _, err := os.Stat(output); err == nil {
+		return []string{output}
+	}
+	return nil
+}
+
+// output is the artifact path suggested to the plugin.
+func (e *PluginExporter) output(session *models.Session, savePath string) string {
+	return filepath.Join(savePath, session.ID+e.Manifest.Extension)
+}
+
+// pluginFactory creates exporters for the plugin at path, reading its manifest each time.
+func pluginFactory(path string) Factory {
+	return func(string) (Exporter, error) {
+		manifest, err := readManifest(pluginName(path), path)
+		if err != nil {
+			return nil, err
+		}
+		return &PluginExporter{Path: path, Manifest: manifest}, nil
+	}
+}
+
+// readManifest asks a plugin for its manifest, filling in the name and a default extension.
+func readManifest(name, path string) (Manifest, error) {
+	manifest := Manifest{Name: name}
+
+	ctx, cancel := context.WithTimeout(context.Background(), manifestTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, path, "--manifest").Output()
+	if err != nil {
+		return manifest, fmt.Errorf("failed to read manifest of %s: %w", path, err)
+	}
+	if err := json.Unmarshal(out, &manifest); err != nil {
+		return Manifest{Name: name}, fmt.Errorf("invalid manifest from %s: %w", path, err)
+	}
+	if manifest.Name != name {
+		return Manifest{Name: name}, fmt.Errorf("manifest of %s names %q, expected %q", path, manifest.Name, name)
+	}
+	if manifest.Extension == "" {
+		manifest.Extension = "." + name
+	}
+	return manifest, nil
+}
+
+// findPlugin resolves the executable for a plugin format name.
+func findPlugin(name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid exporter name %q", name)
+	}
+	return exec.LookPath(PluginPrefix + name)
+}
+
+// discoverPlugins maps plugin names to executables on PATH. Earlier PATH entries win.
+func discoverPlugins() map[string]string {
+	plugins := make(map[string]string)
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasPrefix(entry.Name(), PluginPrefix) {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			name := pluginName(path)
+			if _, ok := plugins[name]; ok || name == "" || !isExecutable(path) {
+				continue
+			}
+			plugins[name] = path
+		}
+	}
+	return plugins
+}
+
+// pluginName extracts the format name from a plugin executable path.
+func pluginName(path string) string {
+	base := filepath.Base(path)
+	if runtime.GOOS == "windows" {
+		base = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	return strings.TrimPrefix(base, PluginPrefix)
+}
+
+// isExecutable reports whether path is a regular file the user may run.
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	return runtime.GOOS == "windows" || info.Mode()&0111 != 0
+}
+
+// optionEnv is the environment variable carrying an option to a plugin.
+func optionEnv(name string) string {
+	return "CAPYTRACE_OPTION_" + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
@@ -0,0 +1,171 @@
+package exporter
+
+import (
+	_ "embed"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/aggregator"
+	"github.com/andev0x/capytrace.nvim/internal/draft"
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+//go:embed templates/postmortem.md
+var postmortemTemplate []byte
+
+// PostmortemTemplateName is the template for {session_id}_postmortem.md.
+const PostmortemTemplateName = "postmortem.md"
+
+// PostmortemExporter exports a session, or a merge of several, as a blameless-postmortem
+// skeleton ({session_id}_postmortem.md) with a UTC timeline and incident phase markers.
+// `capytrace draft --kind postmortem` renders the same document.
+type PostmortemExporter struct {
+	aggregator *aggregator.Aggregator
+
+	// TemplateDir overrides the session's template directory; a postmortem.md found there
+	// replaces the embedded template.
+	TemplateDir string
+}
+
+// NewPostmortemExporter creates a postmortem exporter with the given aggregation rules.
+func NewPostmortemExporter(config *aggregator.AggregatorConfig) *PostmortemExporter {
+	return &PostmortemExporter{aggregator: aggregator.New(config)}
+}
+
+// PostmortemData is the view model passed to postmortem.md. It extends the session
+// view model with the incident phases, the rows of the incident timeline and the draft
+// facts (problem, errors, root cause, tests) shared with `capytrace draft`.
+type PostmortemData struct {
+	*TemplateData
+
+	Draft    *draft.Draft
+	Incident aggregator.Incident
+	Timeline []PostmortemRow // Notes, commands, diagnostics, commits and session gaps
+	Commits  []aggregator.Commit
+	Commands []models.Event // Every terminal command, for the appendix
+
+	// Dated entries of the Detection, Mitigation and Resolution sections, oldest first
+	Detection  []PostmortemEntry
+	Mitigation []PostmortemEntry
+	Resolution []PostmortemEntry // Resolution notes, commits and tests that started passing
+}
+
+// PostmortemEntry is one dated line of a phase section. Exactly one of Note, Commit and
+// Test is set.
+type PostmortemEntry struct {
+	Time   time.Time
+	Note   string
+	Commit *aggregator.Commit
+	Test   *draft.TestRun
+}
+
+// PostmortemRow is a timeline row with its incident phase and whether it made a commit.
+type PostmortemRow struct {
+	TimelineEvent
+	Phase  string // Incident phase marked by a note's tags, or ""
+	Commit bool
+	Failed bool // Commands that exited with a non-zero status
+}
+
+// Export writes the postmortem to savePath.
+func (e *PostmortemExporter) Export(session *models.Session, savePath string) error {
+	content, err := e.Render(session)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(savePath, session.ID+"_postmortem.md"), []byte(content), 0644)
+}
+
+// Render returns the postmortem of a session.
+func (e *PostmortemExporter) Render(session *models.Session) (string, error) {
+	data, err := e.postmortemData(session)
+	if err != nil {
+		return "", err
+	}
+	return renderTemplate(templateDir(e.TemplateDir, session), PostmortemTemplateName, postmortemTemplate, data)
+}
+
+// Artifacts returns the path of the postmortem.
+func (e *PostmortemExporter) Artifacts(session *models.Session, savePath string) []string {
+	return []string{filepath.Join(savePath, session.ID+"_postmortem.md")}
+}
+
+// postmortemData builds the view model. Timeline rows come from GroupTimeline, keeping
+// the event types an incident review cares about.
+func (e *PostmortemExporter) postmortemData(session *models.Session) (*PostmortemData, error) {
+	facts, err := draft.Build(session, e.aggregator, draft.KindPostmortem)
+	if err != nil {
+		return nil, err
+	}
+	data := &PostmortemData{
+		TemplateData: newTemplateData(session, e.aggregator),
+		Draft:        facts,
+		Incident:     e.aggregator.AnalyzeIncident(session),
+		Commits:      facts.Commits,
+	}
+	data.phaseEntries()
+
+	committed := make(map[int64]bool)
+	for _, commit := range data.Commits {
+		committed[commit.Time.UnixNano()] = true
+	}
+	failed := make(map[int64]bool)
+	for _, event := range session.Events {
+		if event.Type != "terminal_command" {
+			continue
+		}
+		data.Commands = append(data.Commands, event)
+		if event.Data.ExitCode != nil && *event.Data.ExitCode != 0 {
+			failed[event.Timestamp.UnixNano()] = true
+		}
+	}
+
+	for _, row := range data.GroupedEvents {
+		switch row.Type {
+		case "annotation", "terminal_command", "lsp_diagnostic", "session_gap":
+		default:
+			continue
+		}
+		pmRow := PostmortemRow{TimelineEvent: row}
+		switch row.Type {
+		case "annotation":
+			pmRow.Phase = aggregator.PhaseOf(row.Details)
+		case "terminal_command":
+			pmRow.Commit = committed[row.Start.UnixNano()]
+			pmRow.Failed = failed[row.Start.UnixNano()]
+		}
+		data.Timeline = append(data.Timeline, pmRow)
+	}
+
+	return data, nil
+}
+
+// phaseEntries sorts the incident markers into their sections and adds the commits and
+// fixed tests to the resolution, each section ordered by time.
+func (d *PostmortemData) phaseEntries() {
+	for _, marker := range d.Incident.Markers {
+		entry := PostmortemEntry{Time: marker.Time, Note: marker.Note}
+		switch marker.Phase {
+		case aggregator.PhaseDetection:
+			d.Detection = append(d.Detection, entry)
+		case aggregator.PhaseMitigation:
+			d.Mitigation = append(d.Mitigation, entry)
+		case aggregator.PhaseResolution:
+			d.Resolution = append(d.Resolution, entry)
+		}
+	}
+	for i := range d.Commits {
+		d.Resolution = append(d.Resolution, PostmortemEntry{Time: d.Commits[i].Time, Commit: &d.Commits[i]})
+	}
+	for i := range d.Draft.FixedTests {
+		test := &d.Draft.FixedTests[i]
+		d.Resolution = append(d.Resolution, PostmortemEntry{Time: test.LastRun, Test: test})
+	}
+
+	for _, entries := range [][]PostmortemEntry{d.Detection, d.Mitigation, d.Resolution} {
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	}
+}
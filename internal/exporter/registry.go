@@ -0,0 +1,428 @@
+package exporter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/andev0x/capytrace.nvim/internal/aggregator"
+	"github.com/andev0x/capytrace.nvim/internal/anonymize"
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+// DefaultFormat is used when a session has no output format.
+const DefaultFormat = "markdown"
+
+// Factory creates an exporter. dataDir is the shared data directory that holds capytrace.db.
+type Factory func(dataDir string) (Exporter, error)
+
+// Manifest describes an exporter. External plugins print theirs as JSON for --manifest.
+type Manifest struct {
+	Name            string           `json:"name"`
+	Extension       string           `json:"extension"`                  // Appended to the session ID to name the artifact, e.g. ".md"; empty when there is no per-session file
+	ExtraExtensions []string         `json:"extra_extensions,omitempty"` // Further artifacts beside Extension, e.g. "_blocks.csv"
+	Description     string           `json:"description,omitempty"`
+	Options         []ManifestOption `json:"options,omitempty"`
+}
+
+// ManifestOption is an export option an exporter reads from the session's export options.
+type ManifestOption struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Default     string `json:"default,omitempty"`
+}
+
+// Available is an exporter that can be used as an output format.
+type Available struct {
+	Manifest
+	Source string `json:"source"`          // "builtin", or the plugin's executable path
+	Error  string `json:"error,omitempty"` // Set when a plugin's manifest could not be read
+}
+
+// registration is a registry entry.
+type registration struct {
+	manifest Manifest
+	factory  Factory
+}
+
+// ArtifactLister is implemented by exporters that can name the files they write.
+type ArtifactLister interface {
+	// Artifacts returns the paths Export writes for the session.
+	Artifacts(session *models.Session, savePath string) []string
+}
+
+// Artifact is a file produced by an exporter.
+type Artifact struct {
+	Format string `json:"format"`
+	Path   string `json:"path"`
+}
+
+// Result is the outcome of one exporter run by Run.
+type Result struct {
+	Format    string
+	Artifacts []Artifact
+	Err       error
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]registration{}
+)
+
+func init() {
+	templateOption := ManifestOption{Name: OptionTemplateDir, Description: "directory of user templates"}
+
+	Register(Manifest{
+		Name:        "markdown",
+		Extension:   ".md",
+		Description: "Session report with quick stats and a grouped timeline",
+		Options:     []ManifestOption{templateOption},
+	}, func(string) (Exporter, error) { return &MarkdownExporter{}, nil })
+	Register(Manifest{
+		Name:        "json",
+		Extension:   "_export.json",
+		Description: "Complete session with every event",
+	}, func(string) (Exporter, error) { return &JSONExporter{}, nil })
+	Register(Manifest{
+		Name:        "html",
+		Extension:   ".html",
+		Description: "Standalone HTML session page",
+		Options:     []ManifestOption{templateOption},
+	}, func(string) (Exporter, error) { return &HTMLExporter{}, nil })
+	Register(Manifest{
+		Name:        "cast",
+		Extension:   ".cast",
+		Description: "asciicast v2 recording playable with asciinema",
+	}, func(string) (Exporter, error) { return NewCastExporter(), nil })
+	Register(Manifest{
+		Name:            "csv",
+		Extension:       "_events.csv",
+		ExtraExtensions: []string{"_blocks.csv", "_files.csv"},
+		Description:     "Spreadsheet tables of events, activity blocks and per-file focus (_events, _blocks, _files)",
+	}, func(string) (Exporter, error) { return NewCSVExporter(aggregator.DefaultConfig()), nil })
+	Register(Manifest{
+		Name:            "tsv",
+		Extension:       "_events.tsv",
+		ExtraExtensions: []string{"_blocks.tsv", "_files.tsv"},
+		Description:     "Tab-separated variant of csv",
+	}, func(string) (Exporter, error) { return NewTSVExporter(aggregator.DefaultConfig()), nil })
+	Register(Manifest{
+		Name:        "ics",
+		Extension:   ".ics",
+		Description: "iCalendar events for time tracking, split at idle gaps",
+		Options: []ManifestOption{{
+			Name:        OptionCalendarGranularity,
+			Description: "session (one event per active period) or block (per run of activity blocks)",
+			Default:     GranularitySession,
+		}},
+	}, func(string) (Exporter, error) { return NewICSExporter(aggregator.DefaultConfig()), nil })
+	Register(Manifest{
+		Name:        "org",
+		Extension:   ".org",
+		Description: "Org document with CLOCK entries per active period, notes, diagnostics and activity blocks",
+	}, func(string) (Exporter, error) { return NewOrgExporter(aggregator.DefaultConfig()), nil })
+	Register(Manifest{
+		Name:        "wakatime",
+		Extension:   ".wakatime.json",
+		Description: "WakaTime heartbeats, optionally sent to a compatible server",
+		Options: []ManifestOption{{
+			Name:        OptionWakaTimeURL,
+			Description: "API base URL to send heartbeats to, authenticated with $WAKATIME_API_KEY",
+		}},
+	}, func(string) (Exporter, error) { return &WakaTimeExporter{}, nil })
+	Register(Manifest{
+		Name:        "postmortem",
+		Extension:   "_postmortem.md",
+		Description: "Blameless-postmortem skeleton with a UTC timeline and #detected/#mitigated/#resolved markers",
+		Options:     []ManifestOption{templateOption},
+	}, func(string) (Exporter, error) { return NewPostmortemExporter(aggregator.DefaultConfig()), nil })
+	Register(Manifest{
+		Name:        "notes",
+		Description: "Section of a dated daily note (Obsidian-style) with YAML front-matter and wiki-links",
+		Options: []ManifestOption{
+			{Name: OptionNotesDir, Description: "vault directory for daily notes", Default: "the notes directory of the save path"},
+			{Name: OptionNotesPath, Description: "daily-note path pattern ({date}, {year}, {month}, {day}, {week}, {project})", Default: DefaultNotesPath},
+			templateOption,
+		},
+	}, func(string) (Exporter, error) { return NewNotesExporter(aggregator.DefaultConfig()), nil })
+	Register(Manifest{
+		Name:        "sqlite",
+		Description: "Rows in the shared capytrace.db database",
+	}, func(dataDir string) (Exporter, error) {
+		if err := os.MkdirAll(dataDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create data directory: %w", err)
+		}
+		return NewSQLiteExporter(dataDir), nil
+	})
+}
+
+// Register makes an exporter available under its manifest name, replacing any previous one.
+// Registered exporters take precedence over plugins of the same name.
+func Register(manifest Manifest, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[manifest.Name] = registration{manifest: manifest, factory: factory}
+}
+
+// Lookup returns the factory for a format name: a registered exporter, or else a
+// capytrace-export-<name> plugin found on PATH.
+func Lookup(name string) (Factory, bool) {
+	registryMu.RLock()
+	entry, ok := registry[name]
+	registryMu.RUnlock()
+	if ok {
+		return entry.factory, true
+	}
+
+	path, err := findPlugin(name)
+	if err != nil {
+		return nil, false
+	}
+	return pluginFactory(path), true
+}
+
+// Formats returns the names of registered exporters and plugins on PATH in sorted order.
+// Plugin names come from their file names; no plugin is run.
+func Formats() []string {
+	registryMu.RLock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	registryMu.RUnlock()
+
+	for name := range discoverPlugins() {
+		if !containsString(names, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// List describes every registered exporter and every plugin on PATH, sorted by name.
+// Plugins whose manifest cannot be read are included with Error set.
+func List() []Available {
+	registryMu.RLock()
+	list := make([]Available, 0, len(registry))
+	for _, entry := range registry {
+		list = append(list, Available{Manifest: entry.manifest, Source: "builtin"})
+	}
+	registryMu.RUnlock()
+
+	seen := make(map[string]bool)
+	for _, available := range list {
+		seen[available.Name] = true
+	}
+	for name, path := range discoverPlugins() {
+		if seen[name] {
+			continue
+		}
+		available := Available{Source: path}
+		manifest, err := readManifest(name, path)
+		available.Manifest = manifest
+		if err != nil {
+			available.Error = err.Error()
+		}
+		list = append(list, available)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// ArtifactSuffixes returns the suffixes exporters append to a session ID to name their
+// artifacts, from every registered manifest and, when plugins is set, the manifests of
+// the plugins on PATH (which runs them with --manifest).
+func ArtifactSuffixes(plugins bool) []string {
+	var manifests []Manifest
+	if plugins {
+		for _, available := range List() {
+			manifests = append(manifests, available.Manifest)
+		}
+	} else {
+		registryMu.RLock()
+		for _, entry := range registry {
+			manifests = append(manifests, entry.manifest)
+		}
+		registryMu.RUnlock()
+	}
+
+	var suffixes []string
+	for _, manifest := range manifests {
+		for _, suffix := range append([]string{manifest.Extension}, manifest.ExtraExtensions...) {
+			if suffix != "" && !containsString(suffixes, suffix) {
+				suffixes = append(suffixes, suffix)
+			}
+		}
+	}
+	sort.Strings(suffixes)
+	return suffixes
+}
+
+// ParseFormats splits an output format list such as "markdown+sqlite+html" or
+// "markdown,json" into format names, dropping duplicates. An empty list means markdown.
+func ParseFormats(outputFormat string) []string {
+	fields := strings.FieldsFunc(outputFormat, func(r rune) bool {
+		return r == '+' || r == ',' || r == ' '
+	})
+
+	var formats []string
+	seen := make(map[string]bool)
+	for _, field := range fields {
+		name := strings.ToLower(field)
+		if !seen[name] {
+			seen[name] = true
+			formats = append(formats, name)
+		}
+	}
+	if len(formats) == 0 {
+		formats = []string{DefaultFormat}
+	}
+	return formats
+}
+
+// HasFormat reports whether an output format list includes name.
+func HasFormat(outputFormat, name string) bool {
+	for _, format := range ParseFormats(outputFormat) {
+		if format == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateFormats reports an error for the first format in an output format list that has
+// no registered exporter and no usable plugin.
+func ValidateFormats(outputFormat string) error {
+	for _, format := range ParseFormats(outputFormat) {
+		registryMu.RLock()
+		_, ok := registry[format]
+		registryMu.RUnlock()
+		if ok {
+			continue
+		}
+
+		path, err := findPlugin(format)
+		if err != nil {
+			return unknownFormat(format)
+		}
+		if _, err := readManifest(format, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AnonymizedDir is the subdirectory of the save path that receives anonymized exports.
+const AnonymizedDir = "anonymized"
+
+// Run exports a session with every format in its output format list concurrently.
+// Each format gets its own Result, in list order, so one failing exporter does not
+// hide the artifacts produced by the others.
+//
+// When the session's export options enable anonymization, every format (plugins
+// included) receives a pseudonymized copy instead and writes into savePath/anonymized,
+// which also holds its own capytrace.db for the sqlite format. The mapping to undo the
+// pseudonyms stays in dataDir. The copy carries no template directory; exporters that
+// render templates are given it directly, so it never appears in an anonymized export.
+func Run(session *models.Session, savePath, dataDir string) []Result {
+	formats := ParseFormats(session.OutputFormat)
+	results := make([]Result, len(formats))
+
+	templates := ""
+	if anonymize.Enabled(session.ExportOptions) {
+		templates = session.ExportOptions[OptionTemplateDir]
+		anon, err := anonymize.Prepare(session, dataDir)
+		if err == nil {
+			savePath = filepath.Join(savePath, AnonymizedDir)
+			err = os.MkdirAll(savePath, 0755)
+		}
+		if err != nil {
+			for i, format := range formats {
+				results[i] = Result{Format: format, Err: fmt.Errorf("failed to anonymize: %w", err)}
+			}
+			return results
+		}
+		session, dataDir = anon, savePath
+	}
+
+	var wg sync.WaitGroup
+	for i, format := range formats {
+		wg.Add(1)
+		go func(i int, format string) {
+			defer wg.Done()
+			results[i] = runOne(session, savePath, dataDir, templates, format)
+		}(i, format)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// templated is implemented by exporters that render user templates.
+type templated interface {
+	setTemplateDir(dir string)
+}
+
+func (e *MarkdownExporter) setTemplateDir(dir string)   { e.TemplateDir = dir }
+func (e *HTMLExporter) setTemplateDir(dir string)       { e.TemplateDir = dir }
+func (e *PostmortemExporter) setTemplateDir(dir string) { e.TemplateDir = dir }
+func (e *NotesExporter) setTemplateDir(dir string)      { e.TemplateDir = dir }
+
+// runOne creates and runs the exporter for a single format. A non-empty templates
+// directory is given to exporters that render templates.
+func runOne(session *models.Session, savePath, dataDir, templates, format string) Result {
+	result := Result{Format: format}
+
+	factory, ok := Lookup(format)
+	if !ok {
+		result.Err = unknownFormat(format)
+		return result
+	}
+
+	exp, err := factory(dataDir)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if t, ok := exp.(templated); ok && templates != "" {
+		t.setTemplateDir(templates)
+	}
+	if err := exp.Export(session, savePath); err != nil {
+		result.Err = err
+		return result
+	}
+
+	if lister, ok := exp.(ArtifactLister); ok {
+		for _, path := range lister.Artifacts(session, savePath) {
+			result.Artifacts = append(result.Artifacts, Artifact{Format: format, Path: path})
+		}
+	}
+	return result
+}
+
+// unknownFormat describes a format with no registered exporter.
+func unknownFormat(format string) error {
+	return fmt.Errorf("unknown output format %q (available: %s)", format, strings.Join(Formats(), ", "))
+}
+
+// Collect flattens results into the produced artifacts and one error describing every
+// failed format, or nil when all succeeded.
+func Collect(results []Result) ([]Artifact, error) {
+	var artifacts []Artifact
+	var failures []string
+	for _, result := range results {
+		artifacts = append(artifacts, result.Artifacts...)
+		if result.Err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", result.Format, result.Err))
+		}
+	}
+	if len(failures) > 0 {
+		return artifacts, fmt.Errorf("export failed for %s", strings.Join(failures, "; "))
+	}
+	return artifacts, nil
+}
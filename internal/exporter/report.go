@@ -0,0 +1,74 @@
+package exporter
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/report"
+)
+
+//go:embed templates/report.md
+var reportMarkdownTemplate []byte
+
+//go:embed templates/report.html
+var reportHTMLTemplate []byte
+
+// reportFuncs are the helpers available to the range report templates.
+var reportFuncs = map[string]interface{}{
+	"duration":   formatDuration,
+	"base":       filepath.Base,
+	"title":      formatEventType,
+	"rangeLabel": rangeLabel,
+}
+
+// RenderReport renders a range report as "markdown", "json" or "html".
+func RenderReport(r *report.Report, format string) ([]byte, error) {
+	switch format {
+	case "json":
+		return json.MarshalIndent(r, "", "  ")
+	case "html":
+		tmpl, err := htmltemplate.New("report").Funcs(reportFuncs).Parse(string(reportHTMLTemplate))
+		if err != nil {
+			return nil, err
+		}
+		var sb strings.Builder
+		if err := tmpl.Execute(&sb, r); err != nil {
+			return nil, err
+		}
+		return []byte(sb.String()), nil
+	case "markdown", "md", "":
+		tmpl, err := template.New("report").Funcs(reportFuncs).Parse(string(reportMarkdownTemplate))
+		if err != nil {
+			return nil, err
+		}
+		var sb strings.Builder
+		if err := tmpl.Execute(&sb, r); err != nil {
+			return nil, err
+		}
+		return []byte(sb.String()), nil
+	default:
+		return nil, fmt.Errorf("unknown report format %q: use markdown, json or html", format)
+	}
+}
+
+// rangeLabel describes the time range covered by a report.
+func rangeLabel(r *report.Report) string {
+	const layout = "2006-01-02"
+	switch {
+	case r.Since != nil && r.Until != nil:
+		// Until is exclusive, so show the last day actually included
+		return r.Since.Format(layout) + " → " + r.Until.Add(-time.Nanosecond).Format(layout)
+	case r.Since != nil:
+		return "since " + r.Since.Format(layout)
+	case r.Until != nil:
+		return "until " + r.Until.Add(-time.Nanosecond).Format(layout)
+	default:
+		return "all sessions"
+	}
+}
@@ -24,6 +24,11 @@ func NewSQLiteExporter(dataDir string) *SQLiteExporter {
 	}
 }
 
+// Artifacts returns the path of the shared database file.
+func (e *SQLiteExporter) Artifacts(session *models.Session, savePath string) []string {
+	return []string{e.dbPath}
+}
+
 // Export writes a session and its events to the SQLite database.
 // Creates tables if they don't exist and handles session updates idempotently.
 func (e *SQLiteExporter) Export(session *models.Session, savePath string) error {
@@ -114,6 +119,8 @@ func (e *SQLiteExporter) createTables(db *sql.DB) error {
 		note TEXT,
 		prev_line INTEGER,
 		prev_column INTEGER,
+		exit_code INTEGER,
+		source TEXT,
 		FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
 	);
 
@@ -122,8 +129,58 @@ func (e *SQLiteExporter) createTables(db *sql.DB) error {
 	CREATE INDEX IF NOT EXISTS idx_events_timestamp ON events(timestamp);
 	`
 
-	_, err := db.Exec(schema)
-	return err
+	if _, err := db.Exec(schema); err != nil {
+		return err
+	}
+	return e.migrate(db)
+}
+
+// eventMigrations lists the events columns added after the first schema, in order.
+var eventMigrations = []struct{ column, decl string }{
+	{"exit_code", "INTEGER"},
+	{"source", "TEXT"},
+}
+
+// migrate adds the columns of eventMigrations that an existing database lacks.
+func (e *SQLiteExporter) migrate(db *sql.DB) error {
+	columns, err := tableColumns(db, "events")
+	if err != nil {
+		return err
+	}
+	for _, m := range eventMigrations {
+		if columns[m.column] {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE events ADD COLUMN %s %s", m.column, m.decl)); err != nil {
+			return fmt.Errorf("failed to add column %s: %w", m.column, err)
+		}
+	}
+	return nil
+}
+
+// tableColumns returns the names of a table's columns.
+func tableColumns(db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			fmt.Fprintf(os.Stderr, "Failed to close rows: %v\n", closeErr)
+		}
+	}()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, typ string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
 }
 
 // insertEvent inserts a single event into the database.
@@ -132,8 +189,8 @@ func (e *SQLiteExporter) insertEvent(tx *sql.Tx, sessionID string, event *models
 		INSERT INTO events (
 			session_id, type, timestamp, filename, line, column, 
 			line_count, changed_tick, file_type, message, level, 
-			command, note, prev_line, prev_column
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			command, note, prev_line, prev_column, exit_code, source
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`,
 		sessionID,
 		event.Type,
@@ -150,6 +207,8 @@ func (e *SQLiteExporter) insertEvent(tx *sql.Tx, sessionID string, event *models
 		nullString(event.Data.Note),
 		nullInt(event.Data.PrevLine),
 		nullInt(event.Data.PrevColumn),
+		event.Data.ExitCode,
+		nullString(event.Source),
 	)
 	return err
 }
@@ -219,6 +278,230 @@ func (e *SQLiteExporter) GetSessionStats(sessionID string) (*models.SessionSumma
 	return &summary, nil
 }
 
+// DeleteSession removes a session and all of its events from the database.
+// It is a no-op when the database has not been created yet.
+func (e *SQLiteExporter) DeleteSession(sessionID string) error {
+	return e.update(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM events WHERE session_id = ?`, sessionID); err != nil {
+			return fmt.Errorf("failed to delete events: %w", err)
+		}
+		if _, err := tx.Exec(`DELETE FROM sessions WHERE id = ?`, sessionID); err != nil {
+			return fmt.Errorf("failed to delete session: %w", err)
+		}
+		return nil
+	})
+}
+
+// RenameSession moves a session and its events to a new ID.
+// It is a no-op when the database has not been created yet.
+func (e *SQLiteExporter) RenameSession(oldID, newID string) error {
+	return e.update(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`UPDATE sessions SET id = ? WHERE id = ?`, newID, oldID); err != nil {
+			return fmt.Errorf("failed to rename session: %w", err)
+		}
+		if _, err := tx.Exec(`UPDATE events SET session_id = ? WHERE session_id = ?`, newID, oldID); err != nil {
+			return fmt.Errorf("failed to rename events: %w", err)
+		}
+		return nil
+	})
+}
+
+// HasSession reports whether the database contains a row for the session.
+func (e *SQLiteExporter) HasSession(sessionID string) (bool, error) {
+	if _, err := os.Stat(e.dbPath); os.IsNotExist(err) {
+		return false, nil
+	}
+
+	db, err := sql.Open("sqlite", e.dbPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() {
+		if closeErr := db.Close(); closeErr != nil {
+			fmt.Fprintf(os.Stderr, "Failed to close database: %v\n", closeErr)
+		}
+	}()
+
+	if err := e.createTables(db); err != nil {
+		return false, fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sessions WHERE id = ?`, sessionID).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// LoadSessions reads every session and its events from the database, oldest first.
+// The database is opened read-only; it returns nil when it has not been created yet.
+func (e *SQLiteExporter) LoadSessions() ([]*models.Session, error) {
+	return e.loadSessions("", "")
+}
+
+// LoadSession reads one session and its events from the database.
+// It returns nil when the session or the database does not exist.
+func (e *SQLiteExporter) LoadSession(sessionID string) (*models.Session, error) {
+	sessions, err := e.loadSessions("WHERE id = ?", "WHERE session_id = ?", sessionID)
+	if err != nil || len(sessions) == 0 {
+		return nil, err
+	}
+	return sessions[0], nil
+}
+
+// loadSessions reads the sessions and events selected by the WHERE clauses, which
+// share args, from a read-only connection.
+func (e *SQLiteExporter) loadSessions(sessionWhere, eventWhere string, args ...interface{}) ([]*models.Session, error) {
+	if _, err := os.Stat(e.dbPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	db, err := sql.Open("sqlite", "file:"+e.dbPath+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() {
+		if closeErr := db.Close(); closeErr != nil {
+			fmt.Fprintf(os.Stderr, "Failed to close database: %v\n", closeErr)
+		}
+	}()
+
+	rows, err := db.Query(`
+		SELECT id, project_path, start_time, end_time, active, output_format
+		FROM sessions `+sessionWhere+` ORDER BY start_time
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []*models.Session
+	byID := make(map[string]*models.Session)
+	for rows.Next() {
+		var session models.Session
+		var endTime sql.NullTime
+		if err := rows.Scan(&session.ID, &session.ProjectPath, &session.StartTime, &endTime, &session.Active, &session.OutputFormat); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		session.EndTime = endTime.Time
+		sessions = append(sessions, &session)
+		byID[session.ID] = &session
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	// Databases written before a migration and opened read-only lack its columns
+	columns, err := tableColumns(db, "events")
+	if err != nil {
+		return nil, err
+	}
+	migrated := ""
+	for _, m := range eventMigrations {
+		if columns[m.column] {
+			migrated += ", " + m.column
+		} else {
+			migrated += ", NULL"
+		}
+	}
+
+	rows, err = db.Query(`
+		SELECT session_id, type, timestamp, filename, line, column,
+			line_count, changed_tick, file_type, message, level,
+			command, note, prev_line, prev_column`+migrated+`
+		FROM events `+eventWhere+` ORDER BY session_id, timestamp, id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			fmt.Fprintf(os.Stderr, "Failed to close rows: %v\n", closeErr)
+		}
+	}()
+
+	for rows.Next() {
+		var sessionID string
+		var event models.Event
+		var filename, fileType, message, level, command, note, source sql.NullString
+		var line, column, lineCount, changedTick, prevLine, prevColumn, exitCode sql.NullInt64
+		if err := rows.Scan(&sessionID, &event.Type, &event.Timestamp, &filename, &line, &column,
+			&lineCount, &changedTick, &fileType, &message, &level,
+			&command, &note, &prevLine, &prevColumn, &exitCode, &source); err != nil {
+			return nil, err
+		}
+
+		session := byID[sessionID]
+		if session == nil {
+			continue
+		}
+		event.Data = models.EventData{
+			Filename:    filename.String,
+			Line:        int(line.Int64),
+			Column:      int(column.Int64),
+			LineCount:   int(lineCount.Int64),
+			ChangedTick: int(changedTick.Int64),
+			FileType:    fileType.String,
+			Message:     message.String,
+			Level:       level.String,
+			Command:     command.String,
+			Note:        note.String,
+			PrevLine:    int(prevLine.Int64),
+			PrevColumn:  int(prevColumn.Int64),
+		}
+		if exitCode.Valid {
+			code := int(exitCode.Int64)
+			event.Data.ExitCode = &code
+		}
+		event.Source = source.String
+		session.Events = append(session.Events, event)
+	}
+
+	return sessions, rows.Err()
+}
+
+// update runs fn inside a transaction on an existing database.
+// It is a no-op when the database has not been created yet.
+func (e *SQLiteExporter) update(fn func(tx *sql.Tx) error) error {
+	if _, err := os.Stat(e.dbPath); os.IsNotExist(err) {
+		return nil
+	}
+
+	db, err := sql.Open("sqlite", e.dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() {
+		if closeErr := db.Close(); closeErr != nil {
+			fmt.Fprintf(os.Stderr, "Failed to close database: %v\n", closeErr)
+		}
+	}()
+
+	if err := e.createTables(db); err != nil {
+		return fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil && rollbackErr != sql.ErrTxDone {
+			fmt.Fprintf(os.Stderr, "Failed to rollback transaction: %v\n", rollbackErr)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
 // Helper functions for NULL handling
 func nullString(s string) interface{} {
 	if s == "" {
@@ -0,0 +1,340 @@
+package exporter
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/aggregator"
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+// OptionTemplateDir is the session export option naming a directory of user templates.
+// Templates found there (session.md, summary.md, session.html) replace the embedded defaults.
+const OptionTemplateDir = "template_dir"
+
+// Template file names, both embedded and looked up in a user template directory.
+const (
+	SessionTemplateName     = "session.md"   // {session_id}.md
+	SummaryTemplateName     = "summary.md"   // SESSION_SUMMARY.md
+	SessionHTMLTemplateName = "session.html" // {session_id}.html
+)
+
+// DefaultTemplates returns the embedded templates by file name, for `capytrace template dump`.
+func DefaultTemplates() map[string][]byte {
+	return map[string][]byte{
+		SessionTemplateName:     sessionTemplate,
+		SummaryTemplateName:     summaryTemplate,
+		SessionHTMLTemplateName: sessionHTMLTemplate,
+		PostmortemTemplateName:  postmortemTemplate,
+		NotesTemplateName:       notesTemplate,
+
+		DiffTemplateName: diffMarkdownTemplate,
+
+		DraftPRTemplateName:     draftPRTemplate,
+		DraftCommitTemplateName: draftCommitTemplate,
+	}
+}
+
+// TemplateData is the view model passed to session.md, summary.md and session.html.
+// It is a stable API for user templates: fields may be added but are never renamed or removed.
+type TemplateData struct {
+	ViewData
+
+	Session        *models.Session          // The raw session, including every event
+	ActivityBlocks []models.ActivityBlock   // Blocks of continuous editing built by the aggregator
+	Analytics      *models.SessionAnalytics // Velocity, focus, idle and error-correction metrics
+
+	Ended       bool          // Whether the session has an end time
+	EndTime     string        // "2006-01-02 15:04:05", empty while active
+	Elapsed     time.Duration // End minus start, zero while active
+	Files       []FileShare   // Analytics.MainFiles sorted by time spent, longest first
+	EventCounts []KeyCount    // Events per type, in the order the types first occur
+}
+
+// ViewData holds the pre-formatted values used by the session report.
+type ViewData struct {
+	ID               string
+	ProjectPath      string
+	StartDate        string // "2006-01-02"
+	StartTime        string // "15:04:05"
+	Duration         string // Human-readable, or "in progress"
+	FileEdits        int
+	CursorMoves      int
+	TerminalCommands int
+	Annotations      int
+	LSPDiagnostics   int
+	EditPercent      int // Share of edits among edits and cursor moves
+	NavPercent       int
+	TotalEvents      int
+	Blocks           int // Number of grouped timeline rows
+	GroupedEvents    []TimelineEvent
+}
+
+// FileShare is the time spent in one file.
+type FileShare struct {
+	File    string
+	Time    time.Duration
+	Percent float64 // Time relative to this file's time plus distraction time
+}
+
+// KeyCount pairs a key with a count, e.g. an event type and how often it occurred.
+type KeyCount struct {
+	Key   string
+	Count int
+}
+
+// templateFuncs are the helpers available to session templates.
+var templateFuncs = template.FuncMap{
+	"duration":    formatDuration,
+	"seconds":     func(s int) time.Duration { return time.Duration(s) * time.Second },
+	"emoji":       EmojiFor,
+	"title":       formatEventType,
+	"base":        filepath.Base,
+	"truncate":    TruncateText,
+	"clock":       func(t time.Time) string { return t.Format("15:04:05") },
+	"datetime":    func(t time.Time) string { return t.Format("2006-01-02 15:04:05") },
+	"percent":     func(f float64) string { return fmt.Sprintf("%.1f", f) },
+	"add":         func(a, b int) int { return a + b },
+	"sub":         func(a, b int) int { return a - b },
+	"mul100":      func(f float64) float64 { return f * 100 },
+	"upper":       strings.ToUpper,
+	"lower":       strings.ToLower,
+	"sortByValue": sortByValue,
+	"sortByKey":   sortByKey,
+	"limit":       limit,
+	"wrap":        wrapText,
+	"utc":         func(t time.Time) string { return t.UTC().Format("2006-01-02 15:04:05Z") },
+	"cell":        tableCell,
+	"items":       func(items []string) string { return tableCell(strings.Join(items, "; ")) },
+	"offset":      formatOffset,
+	"firstOffset": formatFirstOffset,
+	"delta":       formatDelta,
+	"countDelta":  formatCountDelta,
+}
+
+// newTemplateData runs the aggregator and builds the view model for a session.
+func newTemplateData(session *models.Session, agg *aggregator.Aggregator) *TemplateData {
+	blocks, analytics := agg.AggregateSession(session)
+
+	counts := countEvents(session.Events)
+	editPercent, navPercent := focusRatios(counts["file_edit"], counts["cursor_move"])
+	grouped := GroupTimeline(session.Events)
+
+	data := &TemplateData{
+		ViewData: ViewData{
+			ID:               session.ID,
+			ProjectPath:      session.ProjectPath,
+			StartDate:        session.StartTime.Format("2006-01-02"),
+			StartTime:        session.StartTime.Format("15:04:05"),
+			Duration:         formatDurationHuman(session.StartTime, session.EndTime),
+			FileEdits:        counts["file_edit"],
+			CursorMoves:      counts["cursor_move"],
+			TerminalCommands: counts["terminal_command"],
+			Annotations:      counts["annotation"],
+			LSPDiagnostics:   counts["lsp_diagnostic"],
+			EditPercent:      editPercent,
+			NavPercent:       navPercent,
+			TotalEvents:      len(session.Events),
+			Blocks:           len(grouped),
+			GroupedEvents:    grouped,
+		},
+		Session:        session,
+		ActivityBlocks: blocks,
+		Analytics:      analytics,
+		Ended:          !session.EndTime.IsZero(),
+		EventCounts:    countByType(session.Events),
+	}
+
+	if data.Ended {
+		data.EndTime = session.EndTime.Format("2006-01-02 15:04:05")
+		data.Elapsed = session.EndTime.Sub(session.StartTime)
+	}
+
+	for name, seconds := range analytics.MainFiles {
+		data.Files = append(data.Files, FileShare{
+			File:    name,
+			Time:    time.Duration(seconds) * time.Second,
+			Percent: float64(seconds) / float64(analytics.DistractionTime+seconds) * 100,
+		})
+	}
+	sort.Slice(data.Files, func(i, j int) bool {
+		if data.Files[i].Time != data.Files[j].Time {
+			return data.Files[i].Time > data.Files[j].Time
+		}
+		return data.Files[i].File < data.Files[j].File
+	})
+
+	return data
+}
+
+// templateSource returns name from dir when it exists there, falling back to the embedded default.
+func templateSource(dir, name string, fallback []byte) (string, error) {
+	if dir == "" {
+		return string(fallback), nil
+	}
+	path := filepath.Join(dir, name)
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		return string(data), nil
+	case os.IsNotExist(err):
+		return string(fallback), nil
+	default:
+		return "", fmt.Errorf("failed to read template %s: %w", path, err)
+	}
+}
+
+// loadTemplate parses a Markdown template, preferring the copy in dir.
+func loadTemplate(dir, name string, fallback []byte) (*template.Template, error) {
+	source, err := templateSource(dir, name, fallback)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// renderTemplate executes a Markdown template, preferring the copy in dir.
+func renderTemplate(dir, name string, fallback []byte, data interface{}) (string, error) {
+	tmpl, err := loadTemplate(dir, name, fallback)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+	return sb.String(), nil
+}
+
+// renderHTMLTemplate executes an HTML template with contextual escaping, preferring the copy in dir.
+func renderHTMLTemplate(dir, name string, fallback []byte, data *TemplateData) (string, error) {
+	source, err := templateSource(dir, name, fallback)
+	if err != nil {
+		return "", err
+	}
+
+	tmpl, err := htmltemplate.New(name).Funcs(htmltemplate.FuncMap(templateFuncs)).Parse(source)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+	return sb.String(), nil
+}
+
+// templateDir returns the explicit directory, or the one stored in the session's export options.
+func templateDir(dir string, session *models.Session) string {
+	if dir != "" {
+		return dir
+	}
+	return session.ExportOptions[OptionTemplateDir]
+}
+
+// TruncateText shortens s to at most n characters, ending with "..." when cut.
+func TruncateText(n int, s string) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	if n <= 3 {
+		return string(runes[:n])
+	}
+	return string(runes[:n-3]) + "..."
+}
+
+// tableCell makes s safe inside a Markdown table cell by escaping pipes and joining lines.
+func tableCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.Join(strings.Fields(strings.ReplaceAll(s, "\n", " ")), " ")
+}
+
+// wrapText re-flows s into lines of at most n characters, keeping paragraph breaks.
+// Words longer than n are kept whole on their own line.
+func wrapText(n int, s string) string {
+	paragraphs := strings.Split(strings.TrimSpace(s), "\n\n")
+	for i, paragraph := range paragraphs {
+		var lines []string
+		var line string
+		for _, word := range strings.Fields(paragraph) {
+			switch {
+			case line == "":
+				line = word
+			case len([]rune(line))+1+len([]rune(word)) <= n:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+		paragraphs[i] = strings.Join(lines, "\n")
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// countByType counts events per type, listing the types in the order they first occur.
+func countByType(events []models.Event) []KeyCount {
+	var pairs []KeyCount
+	index := make(map[string]int)
+	for _, event := range events {
+		i, ok := index[event.Type]
+		if !ok {
+			i = len(pairs)
+			index[event.Type] = i
+			pairs = append(pairs, KeyCount{Key: event.Type})
+		}
+		pairs[i].Count++
+	}
+	return pairs
+}
+
+// sortByValue turns a count map into pairs sorted by count (highest first), then key.
+func sortByValue(m map[string]int) []KeyCount {
+	pairs := sortByKey(m)
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].Count > pairs[j].Count
+	})
+	return pairs
+}
+
+// sortByKey turns a count map into pairs sorted by key.
+func sortByKey(m map[string]int) []KeyCount {
+	pairs := make([]KeyCount, 0, len(m))
+	for key, count := range m {
+		pairs = append(pairs, KeyCount{Key: key, Count: count})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Key < pairs[j].Key
+	})
+	return pairs
+}
+
+// limit returns at most the first n elements of a slice.
+func limit(n int, list interface{}) (interface{}, error) {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("limit: expected a slice, got %T", list)
+	}
+	if v.Len() > n {
+		v = v.Slice(0, n)
+	}
+	return v.Interface(), nil
+}
@@ -0,0 +1,60 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/andev0x/capytrace.nvim/internal/models"
+	"github.com/andev0x/capytrace.nvim/internal/wakatime"
+)
+
+// OptionWakaTimeURL is the session export option naming a WakaTime-compatible API to send
+// heartbeats to, e.g. http://localhost:3000/api for a self-hosted Wakapi.
+const OptionWakaTimeURL = "wakatime.url"
+
+// WakaTimeExporter exports file activity as WakaTime heartbeats ({session_id}.wakatime.json).
+// When a server URL is configured the heartbeats are also sent to it, authenticated with
+// the key in WAKATIME_API_KEY.
+type WakaTimeExporter struct {
+	URL     string // API base URL; defaults to the session's export option
+	Offline bool   // Only write the file, even when a URL is configured
+	Out     string // File to write instead of {session_id}.wakatime.json in the save path
+}
+
+// Export writes the heartbeats to savePath and sends them when a URL is configured.
+func (e *WakaTimeExporter) Export(session *models.Session, savePath string) error {
+	heartbeats := wakatime.FromSession(session)
+	if heartbeats == nil {
+		heartbeats = []wakatime.Heartbeat{}
+	}
+
+	data, err := json.MarshalIndent(heartbeats, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(e.Artifacts(session, savePath)[0], data, 0644); err != nil {
+		return err
+	}
+
+	url := e.URL
+	if url == "" {
+		url = session.ExportOptions[OptionWakaTimeURL]
+	}
+	if url == "" || e.Offline || len(heartbeats) == 0 {
+		return nil
+	}
+	if err := wakatime.Send(url, os.Getenv(wakatime.APIKeyEnv), heartbeats); err != nil {
+		return fmt.Errorf("failed to send heartbeats: %w", err)
+	}
+	return nil
+}
+
+// Artifacts returns the path of the heartbeat file.
+func (e *WakaTimeExporter) Artifacts(session *models.Session, savePath string) []string {
+	if e.Out != "" {
+		return []string{e.Out}
+	}
+	return []string{filepath.Join(savePath, session.ID+".wakatime.json")}
+}
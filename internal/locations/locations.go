@@ -0,0 +1,282 @@
+// Package locations lists the places a session touched (edited lines, diagnostics,
+// annotations and hotspots) for jumping back to them from the editor's quickfix list.
+package locations
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+// Location kinds accepted by List.
+const (
+	KindEdits       = "edits"
+	KindDiagnostics = "diagnostics"
+	KindAnnotations = "annotations"
+	KindHotspots    = "hotspots"
+)
+
+// Orders accepted by List.
+const (
+	RankCount  = "count"  // Most events first, then most recent
+	RankRecent = "recent" // Most recent first
+)
+
+// Kinds returns the supported location kinds.
+func Kinds() []string {
+	return []string{KindEdits, KindDiagnostics, KindAnnotations, KindHotspots}
+}
+
+// hotspotDiagnosticWeight is how many edits a diagnostic on a line counts as in hotspots.
+const hotspotDiagnosticWeight = 3
+
+// Location is one quickfix entry. The JSON field names match Vim's setqflist() items.
+type Location struct {
+	Filename string    `json:"filename"`
+	Line     int       `json:"lnum"`
+	Column   int       `json:"col"`
+	Text     string    `json:"text"`
+	Type     string    `json:"type,omitempty"` // "E", "W", "I" or "N" for diagnostics
+	Count    int       `json:"count"`          // Events merged into this entry
+	Last     time.Time `json:"last"`           // Time of the latest merged event
+}
+
+// List returns the de-duplicated locations of one kind in the requested order.
+// Relative file names are resolved against the session's project directory.
+func List(session *models.Session, kind, rank string) ([]Location, error) {
+	var list []Location
+	switch kind {
+	case KindEdits:
+		list = edits(session)
+	case KindDiagnostics:
+		list = diagnostics(session)
+	case KindAnnotations:
+		list = annotations(session)
+	case KindHotspots:
+		list = hotspots(session)
+	default:
+		return nil, fmt.Errorf("unknown location kind %q: use %s", kind, strings.Join(Kinds(), ", "))
+	}
+
+	switch rank {
+	case RankCount, "":
+		sort.SliceStable(list, func(i, j int) bool {
+			if list[i].Count != list[j].Count {
+				return list[i].Count > list[j].Count
+			}
+			return list[i].Last.After(list[j].Last)
+		})
+	case RankRecent:
+		sort.SliceStable(list, func(i, j int) bool {
+			return list[i].Last.After(list[j].Last)
+		})
+	default:
+		return nil, fmt.Errorf("unknown rank %q: use %s or %s", rank, RankCount, RankRecent)
+	}
+	return list, nil
+}
+
+// WriteErrorformat writes locations as "file:line:col: text" lines, which Vim's default
+// 'errorformat' parses.
+func WriteErrorformat(w io.Writer, list []Location) error {
+	for _, loc := range list {
+		if _, err := fmt.Fprintf(w, "%s:%d:%d: %s\n", loc.Filename, loc.Line, loc.Column, oneLine(loc.Text)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collector merges events that share a key into one location, keeping first-seen order.
+type collector struct {
+	index map[string]int
+	list  []Location
+}
+
+func newCollector() *collector {
+	return &collector{index: make(map[string]int)}
+}
+
+// add merges loc into the entry for key, letting the latest event set column and text.
+func (c *collector) add(key string, loc Location, weight int) *Location {
+	i, ok := c.index[key]
+	if !ok {
+		i = len(c.list)
+		c.index[key] = i
+		c.list = append(c.list, loc)
+		c.list[i].Count = 0
+	}
+	entry := &c.list[i]
+	entry.Count += weight
+	if !loc.Last.Before(entry.Last) {
+		entry.Last = loc.Last
+		entry.Column = loc.Column
+		if loc.Text != "" {
+			entry.Text = loc.Text
+		}
+	}
+	return entry
+}
+
+// edits merges file edits by file and line, showing the latest text of the line.
+func edits(session *models.Session) []Location {
+	c := newCollector()
+	for _, event := range session.Events {
+		if event.Type != "file_edit" || event.Data.Filename == "" {
+			continue
+		}
+		file := resolve(session.ProjectPath, event.Data.Filename)
+		loc := at(file, event)
+		loc.Text = strings.TrimSpace(event.Data.LineText)
+		c.add(fmt.Sprintf("%s:%d", file, loc.Line), loc, 1)
+	}
+	for i := range c.list {
+		loc := &c.list[i]
+		if loc.Text == "" {
+			loc.Text = plural(loc.Count, "edit")
+		} else {
+			loc.Text = fmt.Sprintf("%s (%s)", loc.Text, plural(loc.Count, "edit"))
+		}
+	}
+	return c.list
+}
+
+// diagnostics merges LSP diagnostics by file, line and message.
+func diagnostics(session *models.Session) []Location {
+	c := newCollector()
+	for _, event := range session.Events {
+		if event.Type != "lsp_diagnostic" || event.Data.Filename == "" {
+			continue
+		}
+		file := resolve(session.ProjectPath, event.Data.Filename)
+		loc := at(file, event)
+		loc.Text = event.Data.Message
+		loc.Type = qfType(event.Data.Level)
+		entry := c.add(fmt.Sprintf("%s:%d:%s", file, loc.Line, event.Data.Message), loc, 1)
+		entry.Type = loc.Type
+	}
+	return c.list
+}
+
+// annotations places each note at the last file position recorded before it, since
+// notes themselves carry no location. Notes written before any file activity are skipped.
+func annotations(session *models.Session) []Location {
+	c := newCollector()
+	var last *models.Event
+	for i := range session.Events {
+		event := &session.Events[i]
+		if event.Type != "annotation" {
+			if event.Data.Filename != "" && event.Data.Line > 0 {
+				last = event
+			}
+			continue
+		}
+		if last == nil || strings.TrimSpace(event.Data.Note) == "" {
+			continue
+		}
+		file := resolve(session.ProjectPath, last.Data.Filename)
+		loc := at(file, *last)
+		loc.Text = event.Data.Note
+		loc.Last = event.Timestamp
+		loc.Type = "N"
+		c.add(fmt.Sprintf("%s:%d:%s", file, loc.Line, event.Data.Note), loc, 1)
+	}
+	return c.list
+}
+
+// hotspots scores lines by edits plus weighted diagnostics.
+func hotspots(session *models.Session) []Location {
+	c := newCollector()
+	type tally struct{ edits, diagnostics int }
+	tallies := make(map[string]*tally)
+
+	for _, event := range session.Events {
+		if (event.Type != "file_edit" && event.Type != "lsp_diagnostic") || event.Data.Filename == "" {
+			continue
+		}
+		file := resolve(session.ProjectPath, event.Data.Filename)
+		loc := at(file, event)
+		key := fmt.Sprintf("%s:%d", file, loc.Line)
+		if tallies[key] == nil {
+			tallies[key] = &tally{}
+		}
+
+		weight := 1
+		if event.Type == "lsp_diagnostic" {
+			weight = hotspotDiagnosticWeight
+			tallies[key].diagnostics++
+		} else {
+			loc.Text = strings.TrimSpace(event.Data.LineText)
+			tallies[key].edits++
+		}
+		c.add(key, loc, weight)
+	}
+
+	for i := range c.list {
+		loc := &c.list[i]
+		t := tallies[fmt.Sprintf("%s:%d", loc.Filename, loc.Line)]
+		summary := plural(t.edits, "edit")
+		if t.diagnostics > 0 {
+			summary += ", " + plural(t.diagnostics, "diagnostic")
+		}
+		if loc.Text != "" {
+			summary += ": " + loc.Text
+		}
+		loc.Text = summary
+	}
+	return c.list
+}
+
+// at returns a location for an event's position. Lines and columns start at 1; recorded
+// columns come from nvim_win_get_cursor and start at 0.
+func at(file string, event models.Event) Location {
+	line, col := event.Data.Line, event.Data.Column+1
+	if line < 1 {
+		line = 1
+	}
+	if col < 1 {
+		col = 1
+	}
+	return Location{Filename: file, Line: line, Column: col, Last: event.Timestamp}
+}
+
+// resolve makes a recorded file name absolute using the project directory.
+func resolve(projectPath, file string) string {
+	if filepath.IsAbs(file) || projectPath == "" {
+		return file
+	}
+	return filepath.Join(projectPath, file)
+}
+
+// qfType maps an LSP severity to a quickfix type.
+func qfType(level string) string {
+	switch strings.ToUpper(level) {
+	case "ERROR":
+		return "E"
+	case "WARN", "WARNING":
+		return "W"
+	case "INFO", "INFORMATION":
+		return "I"
+	case "HINT":
+		return "N"
+	default:
+		return ""
+	}
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// oneLine joins multi-line text so each location stays on one errorformat line.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
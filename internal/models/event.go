@@ -9,6 +9,10 @@ type Event struct {
 	Type      string    `json:"type"`
 	Timestamp time.Time `json:"timestamp"`
 	Data      EventData `json:"data"`
+
+	// Source is the ID of the session the event was originally recorded in.
+	// It is only set on events of sessions produced by merging.
+	Source string `json:"source,omitempty"`
 }
 
 // EventData contains the payload information for different event types.
@@ -30,7 +34,8 @@ type EventData struct {
 	Level   string `json:"level,omitempty"`
 
 	// Terminal events
-	Command string `json:"command,omitempty"`
+	Command  string `json:"command,omitempty"`
+	ExitCode *int   `json:"exit_code,omitempty"` // nil when the command's outcome is unknown
 
 	// Annotation events
 	Note string `json:"note,omitempty"`
@@ -50,6 +55,10 @@ type Session struct {
 	EndTime      time.Time `json:"end_time,omitempty"`
 	Events       []Event   `json:"events"`
 	Active       bool      `json:"active"`
+
+	// ExportOptions are exporter settings chosen when the session was started
+	// (e.g. "template_dir"), so periodic and final exports render the same way.
+	ExportOptions map[string]string `json:"export_options,omitempty"`
 }
 
 // SessionSummary provides statistics about a session for display purposes.
@@ -8,14 +8,16 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/andev0x/capytrace.nvim/internal/aggregator"
+	"github.com/andev0x/capytrace.nvim/internal/anonymize"
 	"github.com/andev0x/capytrace.nvim/internal/exporter"
 	"github.com/andev0x/capytrace.nvim/internal/filter"
 	"github.com/andev0x/capytrace.nvim/internal/models"
+	"github.com/andev0x/capytrace.nvim/internal/store"
 )
 
 var (
@@ -23,6 +25,42 @@ var (
 	activeSessionsMu sync.RWMutex
 )
 
+// Publisher receives live updates from recording sessions.
+// Implementations must not block; see stream.Hub.
+type Publisher interface {
+	PublishEvent(sessionID string, event models.Event)
+	PublishAnalytics(sessionID string, analytics *models.SessionAnalytics)
+}
+
+var (
+	publisher   Publisher
+	publisherMu sync.RWMutex
+)
+
+// holdLocks is set in the process that records sessions (the daemon). Only that
+// process writes lock files; short-lived CLI invocations never take them.
+var holdLocks atomic.Bool
+
+// HoldLocks makes this process take the lock file of every session it registers
+// as active, so other capytrace processes refuse to modify those sessions.
+func HoldLocks() {
+	holdLocks.Store(true)
+}
+
+// SetPublisher makes every accepted event and regenerated analytics snapshot available
+// to p, e.g. for the daemon's live stream. Pass nil to stop publishing.
+func SetPublisher(p Publisher) {
+	publisherMu.Lock()
+	publisher = p
+	publisherMu.Unlock()
+}
+
+func currentPublisher() Publisher {
+	publisherMu.RLock()
+	defer publisherMu.RUnlock()
+	return publisher
+}
+
 // Session wraps a models.Session with additional runtime state and filtering capabilities.
 type Session struct {
 	*models.Session
@@ -88,6 +126,19 @@ func (s *Session) regenerateSummary() {
 		// Log error but don't fail the session
 		fmt.Fprintf(os.Stderr, "Failed to regenerate session summary: %v\n", err)
 	}
+
+	// Daily notes are updated in place, so they can follow the session as it grows.
+	// Anonymized notes are only written by the final export.
+	if exporter.HasFormat(sessionCopy.OutputFormat, "notes") && !anonymize.Enabled(sessionCopy.ExportOptions) {
+		if err := exporter.NewNotesExporter(s.aggregatorConfig).Export(&sessionCopy, s.SavePath); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to update daily note: %v\n", err)
+		}
+	}
+
+	if p := currentPublisher(); p != nil {
+		_, analytics := aggregator.New(s.aggregatorConfig).AggregateSession(&sessionCopy)
+		p.PublishAnalytics(s.ID, analytics)
+	}
 }
 
 // stopPeriodicAggregation stops the background aggregation goroutine.
@@ -100,9 +151,7 @@ func (s *Session) stopPeriodicAggregation() {
 
 // Start begins recording a new debugging session and persists it to disk.
 func (s *Session) Start() error {
-	activeSessionsMu.Lock()
-	activeSessions[s.ID] = s
-	activeSessionsMu.Unlock()
+	s.register()
 
 	// Record initial event
 	if err := s.addEvent(models.Event{
@@ -128,6 +177,7 @@ func (s *Session) End() error {
 	// Flush any pending cursor events
 	if pendingEvent := s.cursorFilter.FlushPending(); pendingEvent != nil {
 		s.Events = append(s.Events, *pendingEvent)
+		s.publish(*pendingEvent)
 	}
 
 	s.cursorFilter.Stop()
@@ -135,17 +185,22 @@ func (s *Session) End() error {
 	s.Active = false
 
 	// Record end event
-	s.Events = append(s.Events, models.Event{
+	endEvent := models.Event{
 		Type:      "session_end",
 		Timestamp: s.EndTime,
 		Data: models.EventData{
 			Note: "Debugging session ended",
 		},
-	})
+	}
+	s.Events = append(s.Events, endEvent)
+	s.publish(endEvent)
 
 	activeSessionsMu.Lock()
 	delete(activeSessions, s.ID)
 	activeSessionsMu.Unlock()
+	if holdLocks.Load() {
+		store.RemoveLock(s.SavePath, s.ID)
+	}
 	s.mu.Unlock()
 
 	// Save raw JSON
@@ -204,11 +259,26 @@ func (s *Session) RecordEdit(filename, line, col, lineCount, changedTick, lineTe
 
 // RecordTerminalCommand records a terminal command execution.
 func (s *Session) RecordTerminalCommand(command string) error {
+	return s.recordTerminal(command, nil)
+}
+
+// RecordTerminalExit records a finished terminal command together with its exit code.
+func (s *Session) RecordTerminalExit(command, exitCode string) error {
+	code, err := strconv.Atoi(exitCode)
+	if err != nil {
+		return fmt.Errorf("invalid exit code %q: %w", exitCode, err)
+	}
+	return s.recordTerminal(command, &code)
+}
+
+// recordTerminal records a terminal_command event, committing pending cursor moves first.
+func (s *Session) recordTerminal(command string, exitCode *int) error {
 	event := models.Event{
 		Type:      "terminal_command",
 		Timestamp: time.Now(),
 		Data: models.EventData{
-			Command: command,
+			Command:  command,
+			ExitCode: exitCode,
 		},
 	}
 
@@ -280,15 +350,24 @@ func (s *Session) RecordLSPDiagnostic(filename, line, col, message, level string
 	return s.addEvent(event)
 }
 
-// addEvent appends an event to the session and persists it.
+// addEvent appends an event to the session, publishes it and persists it.
 func (s *Session) addEvent(event models.Event) error {
 	s.mu.Lock()
 	s.Events = append(s.Events, event)
 	s.mu.Unlock()
 
+	s.publish(event)
+
 	return s.save()
 }
 
+// publish forwards an accepted event to the live stream, if one is attached.
+func (s *Session) publish(event models.Event) {
+	if p := currentPublisher(); p != nil {
+		p.PublishEvent(s.ID, event)
+	}
+}
+
 // save persists the session state to disk as JSON (The Truth).
 func (s *Session) save() error {
 	s.mu.Lock()
@@ -314,33 +393,20 @@ func LoadSession(sessionID string, savePath string, filterConfig *filter.FilterC
 	activeSessionsMu.RUnlock()
 
 	// Try to load from file (try both _raw.json and .json for backwards compatibility)
-	sessionPath := filepath.Join(savePath, sessionID+"_raw.json")
-	data, err := os.ReadFile(sessionPath)
+	modelSession, err := store.Read(savePath, sessionID)
 	if err != nil {
-		// Try old naming scheme
-		sessionPath = filepath.Join(savePath, sessionID+".json")
-		data, err = os.ReadFile(sessionPath)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	var modelSession models.Session
-	if err := json.Unmarshal(data, &modelSession); err != nil {
 		return nil, err
 	}
 
 	session := &Session{
-		Session:          &modelSession,
+		Session:          modelSession,
 		cursorFilter:     filter.NewCursorFilter(filterConfig),
 		aggregatorConfig: aggregator.DefaultConfig(),
 		stopPeriodicChan: make(chan struct{}),
 	}
 
 	if session.Active {
-		activeSessionsMu.Lock()
-		activeSessions[sessionID] = session
-		activeSessionsMu.Unlock()
+		session.register()
 
 		// Restart periodic aggregation for active sessions
 		session.startPeriodicAggregation(5 * time.Minute)
@@ -349,6 +415,32 @@ func LoadSession(sessionID string, savePath string, filterConfig *filter.FilterC
 	return session, nil
 }
 
+// register marks the session as active in this process and, when the process holds
+// locks, takes its lock file so that other capytrace processes do not modify it while
+// it is being recorded.
+func (s *Session) register() {
+	activeSessionsMu.Lock()
+	activeSessions[s.ID] = s
+	activeSessionsMu.Unlock()
+
+	if !holdLocks.Load() {
+		return
+	}
+	if err := store.WriteLock(s.SavePath, s.ID); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write session lock: %v\n", err)
+	}
+}
+
+// IsHeld reports whether a session is currently being recorded, either by this
+// process (e.g. the daemon) or by another running capytrace process.
+func IsHeld(sessionID, savePath string) bool {
+	activeSessionsMu.RLock()
+	_, exists := activeSessions[sessionID]
+	activeSessionsMu.RUnlock()
+
+	return exists || store.Held(savePath, sessionID)
+}
+
 // ListSessions returns a list of all saved session IDs in the given directory.
 func ListSessions(savePath string) ([]string, error) {
 	files, err := os.ReadDir(savePath)
@@ -360,21 +452,11 @@ func ListSessions(savePath string) ([]string, error) {
 	seen := make(map[string]bool)
 
 	for _, file := range files {
-		name := file.Name()
-
-		// Handle both _raw.json and .json extensions
-		if strings.HasSuffix(name, "_raw.json") {
-			sessionID := strings.TrimSuffix(name, "_raw.json")
-			if !seen[sessionID] {
-				sessions = append(sessions, sessionID)
-				seen[sessionID] = true
-			}
-		} else if strings.HasSuffix(name, ".json") && !strings.HasSuffix(name, "_export.json") {
-			sessionID := strings.TrimSuffix(name, ".json")
-			if !seen[sessionID] {
-				sessions = append(sessions, sessionID)
-				seen[sessionID] = true
-			}
+		// Handles both _raw.json and legacy .json names
+		sessionID, ok := store.SessionFileID(file.Name())
+		if ok && !seen[sessionID] {
+			sessions = append(sessions, sessionID)
+			seen[sessionID] = true
 		}
 	}
 
@@ -384,34 +466,20 @@ func ListSessions(savePath string) ([]string, error) {
 // ResumeSession loads a previously saved session and marks it as active again.
 func ResumeSession(sessionName, savePath string, filterConfig *filter.FilterConfig) (*Session, error) {
 	// Try to load from file (try both _raw.json and .json for backwards compatibility)
-	sessionPath := filepath.Join(savePath, sessionName+"_raw.json")
-	data, err := os.ReadFile(sessionPath)
+	modelSession, err := store.Read(savePath, sessionName)
 	if err != nil {
-		// Try old naming scheme
-		sessionPath = filepath.Join(savePath, sessionName+".json")
-		data, err = os.ReadFile(sessionPath)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	var modelSession models.Session
-	if err := json.Unmarshal(data, &modelSession); err != nil {
 		return nil, err
 	}
 
 	session := &Session{
-		Session:          &modelSession,
+		Session:          modelSession,
 		cursorFilter:     filter.NewCursorFilter(filterConfig),
 		aggregatorConfig: aggregator.DefaultConfig(),
 		stopPeriodicChan: make(chan struct{}),
 	}
 
 	session.Active = true
-
-	activeSessionsMu.Lock()
-	activeSessions[session.ID] = session
-	activeSessionsMu.Unlock()
+	session.register()
 
 	// Start periodic aggregation
 	session.startPeriodicAggregation(5 * time.Minute)
@@ -0,0 +1,176 @@
+// Package replay plays back a recorded session on a virtual clock.
+// Playback is driven from the raw events alone, so the project files do not need to exist.
+package replay
+
+import (
+	"errors"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+// speeds are the playback multipliers offered by Faster and Slower.
+var speeds = []float64{0.5, 1, 2, 4, 8, 16, 32, 64, 128}
+
+// Player tracks the playback position in a session.
+type Player struct {
+	Session  *models.Session
+	Playing  bool
+	SkipIdle bool          // Jump over quiet periods longer than MaxGap
+	MaxGap   time.Duration // Longest stretch of session time played without events when SkipIdle is set
+
+	clock time.Time // Current position on the session's clock
+	pos   int       // Number of events that have happened at clock
+	speed int       // Index into speeds
+}
+
+// New creates a paused player positioned at the first event.
+func New(session *models.Session, maxGap time.Duration) (*Player, error) {
+	if len(session.Events) == 0 {
+		return nil, errors.New("session has no events")
+	}
+	p := &Player{
+		Session:  session,
+		SkipIdle: true,
+		MaxGap:   maxGap,
+		speed:    1,
+	}
+	p.Seek(0)
+	return p, nil
+}
+
+// Clock returns the current position on the session's clock.
+func (p *Player) Clock() time.Time {
+	return p.clock
+}
+
+// Position returns how many events have been played.
+func (p *Player) Position() int {
+	return p.pos
+}
+
+// Played returns the events that have happened so far.
+func (p *Player) Played() []models.Event {
+	return p.Session.Events[:p.pos]
+}
+
+// Speed returns the current playback multiplier.
+func (p *Player) Speed() float64 {
+	return speeds[p.speed]
+}
+
+// SetSpeed selects the closest supported multiplier not above x.
+func (p *Player) SetSpeed(x float64) {
+	p.speed = 0
+	for i, s := range speeds {
+		if s <= x {
+			p.speed = i
+		}
+	}
+}
+
+// Faster increases the playback multiplier.
+func (p *Player) Faster() {
+	if p.speed < len(speeds)-1 {
+		p.speed++
+	}
+}
+
+// Slower decreases the playback multiplier.
+func (p *Player) Slower() {
+	if p.speed > 0 {
+		p.speed--
+	}
+}
+
+// Done reports whether every event has been played.
+func (p *Player) Done() bool {
+	return p.pos >= len(p.Session.Events)
+}
+
+// Start returns the time of the first event.
+func (p *Player) Start() time.Time {
+	return p.Session.Events[0].Timestamp
+}
+
+// End returns the time of the last event.
+func (p *Player) End() time.Time {
+	return p.Session.Events[len(p.Session.Events)-1].Timestamp
+}
+
+// Advance moves the clock forward by elapsed wall time scaled by the speed.
+// Breaks between merged sessions are always skipped, and other quiet periods
+// longer than MaxGap are skipped when SkipIdle is set.
+func (p *Player) Advance(elapsed time.Duration) {
+	if !p.Playing || p.Done() {
+		return
+	}
+
+	p.clock = p.clock.Add(time.Duration(float64(elapsed) * p.Speed()))
+
+	if p.pos > 0 && !p.Done() {
+		prev := p.Session.Events[p.pos-1]
+		next := p.Session.Events[p.pos]
+		quiet := p.clock.Sub(prev.Timestamp)
+		if prev.Type == "session_gap" || (p.SkipIdle && p.MaxGap > 0 && quiet >= p.MaxGap) {
+			if p.clock.Before(next.Timestamp) {
+				p.clock = next.Timestamp
+			}
+		}
+	}
+
+	for !p.Done() && !p.Session.Events[p.pos].Timestamp.After(p.clock) {
+		p.pos++
+	}
+	if p.Done() {
+		p.Playing = false
+	}
+}
+
+// Seek moves playback to just after the event at index.
+func (p *Player) Seek(index int) {
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(p.Session.Events) {
+		index = len(p.Session.Events) - 1
+	}
+	p.clock = p.Session.Events[index].Timestamp
+	p.pos = index + 1
+}
+
+// Step moves one event forward (delta > 0) or back (delta < 0).
+func (p *Player) Step(delta int) {
+	p.Seek(p.pos - 1 + delta)
+}
+
+// Next seeks to the next event matching one of types and reports whether one was found.
+func (p *Player) Next(types ...string) bool {
+	for i := p.pos; i < len(p.Session.Events); i++ {
+		if hasType(p.Session.Events[i], types) {
+			p.Seek(i)
+			return true
+		}
+	}
+	return false
+}
+
+// Prev seeks to the previous event matching one of types and reports whether one was found.
+func (p *Player) Prev(types ...string) bool {
+	for i := p.pos - 2; i >= 0; i-- {
+		if hasType(p.Session.Events[i], types) {
+			p.Seek(i)
+			return true
+		}
+	}
+	return false
+}
+
+func hasType(event models.Event, types []string) bool {
+	for _, t := range types {
+		if event.Type == t {
+			return true
+		}
+	}
+	return false
+}
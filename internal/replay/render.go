@@ -0,0 +1,225 @@
+package replay
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/andev0x/capytrace.nvim/internal/aggregator"
+	"github.com/andev0x/capytrace.nvim/internal/exporter"
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+// KeyHelp lists the key bindings shown in the footer.
+const KeyHelp = "space play/pause  +/- speed  ←/→ step  n/N note  d/D diagnostic  i idle-skip  g/G start/end  q quit"
+
+// ANSI styles used by the renderer.
+const (
+	styleReset = "\033[0m"
+	styleBold  = "\033[1m"
+	styleDim   = "\033[2m"
+	styleRed   = "\033[31m"
+	styleGreen = "\033[32m"
+	styleCyan  = "\033[36m"
+)
+
+// Render draws one frame of the player into a width x height screen.
+func Render(p *Player, width, height int) string {
+	if width < 40 {
+		width = 40
+	}
+	if height < 16 {
+		height = 16
+	}
+
+	played := p.Played()
+	var lines []string
+
+	// Header: session, state, speed, clock and progress
+	state := "⏸ paused"
+	if p.Playing {
+		state = "▶ playing"
+	} else if p.Done() {
+		state = "■ finished"
+	}
+	idle := "off"
+	if p.SkipIdle {
+		idle = p.MaxGap.String()
+	}
+	lines = append(lines, fmt.Sprintf("%s🦦 %s%s  %s  %gx  idle-skip %s  %s",
+		styleBold, p.Session.ID, styleReset, state, p.Speed(), idle, p.Clock().Local().Format("2006-01-02 15:04:05")))
+	lines = append(lines, progressBar(p, width))
+	lines = append(lines, "")
+
+	// Current file and the last line edited in it
+	file, line, text := currentFile(played)
+	lines = append(lines, section("Current File", width))
+	if file == "" {
+		lines = append(lines, styleDim+"  (none yet)"+styleReset)
+	} else {
+		loc := aggregator.RelativePath(p.Session.ProjectPath, file)
+		if line > 0 {
+			loc = fmt.Sprintf("%s:%d", loc, line)
+		}
+		lines = append(lines, "  "+styleCyan+loc+styleReset)
+		if text != "" {
+			lines = append(lines, "  "+styleDim+strings.TrimSpace(text)+styleReset)
+		}
+	}
+
+	// Split the remaining rows between the panes
+	remaining := height - len(lines) - 2
+	editRows := remaining * 30 / 100
+	commandRows := remaining * 20 / 100
+	noteRows := remaining * 20 / 100
+	timelineRows := remaining - editRows - commandRows - noteRows
+
+	lines = append(lines, pane("Recent Edits", width, editRows, last(played, editRows-1, func(ev models.Event) bool {
+		return ev.Type == "file_edit"
+	}), func(ev models.Event) string {
+		loc := aggregator.RelativePath(p.Session.ProjectPath, ev.Data.Filename)
+		if ev.Data.Line > 0 {
+			loc = fmt.Sprintf("%s:%d", loc, ev.Data.Line)
+		}
+		return fmt.Sprintf("%s %s  %s", clock(ev), loc, strings.TrimSpace(ev.Data.LineText))
+	})...)
+
+	lines = append(lines, pane("Terminal", width, commandRows, last(played, commandRows-1, func(ev models.Event) bool {
+		return ev.Type == "terminal_command"
+	}), func(ev models.Event) string {
+		status := ""
+		if ev.Data.ExitCode != nil {
+			if *ev.Data.ExitCode == 0 {
+				status = styleGreen + " ✓" + styleReset
+			} else {
+				status = fmt.Sprintf("%s ✗ %d%s", styleRed, *ev.Data.ExitCode, styleReset)
+			}
+		}
+		return fmt.Sprintf("%s $ %s%s", clock(ev), ev.Data.Command, status)
+	})...)
+
+	lines = append(lines, pane("Notes & Diagnostics", width, noteRows, last(played, noteRows-1, func(ev models.Event) bool {
+		return ev.Type == "annotation" || ev.Type == "lsp_diagnostic"
+	}), func(ev models.Event) string {
+		if ev.Type == "lsp_diagnostic" {
+			return fmt.Sprintf("%s %s %s%s%s %s", clock(ev), exporter.EmojiFor(ev.Type),
+				styleRed, strings.ToUpper(ev.Data.Level), styleReset, ev.Data.Message)
+		}
+		return fmt.Sprintf("%s %s %s", clock(ev), exporter.EmojiFor(ev.Type), ev.Data.Note)
+	})...)
+
+	lines = append(lines, pane("Timeline", width, timelineRows, last(played, timelineRows-1, func(ev models.Event) bool {
+		return ev.Type != "cursor_move"
+	}), func(ev models.Event) string {
+		detail := exporter.DetailFor(ev)
+		if detail == "" && ev.Data.Filename != "" {
+			detail = aggregator.RelativePath(p.Session.ProjectPath, ev.Data.Filename)
+		}
+		return fmt.Sprintf("%s %s %-16s %s", clock(ev), exporter.EmojiFor(ev.Type), ev.Type, detail)
+	})...)
+
+	for len(lines) < height-1 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, styleDim+KeyHelp+styleReset)
+
+	for i, l := range lines {
+		lines[i] = fit(l, width)
+	}
+	return strings.Join(lines, "\r\n")
+}
+
+// progressBar draws the position between the first and last event.
+func progressBar(p *Player, width int) string {
+	total := p.End().Sub(p.Start())
+	done := p.Clock().Sub(p.Start())
+	label := fmt.Sprintf(" %s / %s  event %d/%d", formatOffset(done), formatOffset(total), p.Position(), len(p.Session.Events))
+
+	barWidth := width - utf8.RuneCountInString(label) - 2
+	if barWidth < 10 {
+		barWidth = 10
+	}
+	filled := barWidth
+	if total > 0 {
+		filled = int(float64(barWidth) * float64(done) / float64(total))
+	}
+	if filled > barWidth {
+		filled = barWidth
+	}
+	return "[" + styleGreen + strings.Repeat("█", filled) + styleReset + strings.Repeat("░", barWidth-filled) + "]" + label
+}
+
+// pane renders a titled section with up to rows-1 entries.
+func pane(title string, width, rows int, events []models.Event, format func(models.Event) string) []string {
+	if rows <= 0 {
+		return nil
+	}
+	lines := []string{section(title, width)}
+	for _, ev := range events {
+		lines = append(lines, "  "+format(ev))
+	}
+	for len(lines) < rows {
+		lines = append(lines, "")
+	}
+	return lines
+}
+
+func section(title string, width int) string {
+	rule := width - utf8.RuneCountInString(title) - 4
+	if rule < 0 {
+		rule = 0
+	}
+	return styleBold + "── " + title + " " + styleReset + styleDim + strings.Repeat("─", rule) + styleReset
+}
+
+// last returns up to n of the most recent events matching keep, oldest first.
+func last(events []models.Event, n int, keep func(models.Event) bool) []models.Event {
+	var picked []models.Event
+	for i := len(events) - 1; i >= 0 && len(picked) < n; i-- {
+		if keep(events[i]) {
+			picked = append(picked, events[i])
+		}
+	}
+	for i, j := 0, len(picked)-1; i < j; i, j = i+1, j-1 {
+		picked[i], picked[j] = picked[j], picked[i]
+	}
+	return picked
+}
+
+// currentFile returns the file and line of the latest positional event and the last edited text in that file.
+func currentFile(events []models.Event) (string, int, string) {
+	var file, text string
+	var line int
+	for i := len(events) - 1; i >= 0; i-- {
+		ev := events[i]
+		if ev.Data.Filename == "" || ev.Type == "lsp_diagnostic" {
+			continue
+		}
+		if file == "" {
+			file, line = ev.Data.Filename, ev.Data.Line
+		}
+		if ev.Data.Filename == file && ev.Type == "file_edit" && ev.Data.LineText != "" {
+			text = ev.Data.LineText
+			break
+		}
+	}
+	return file, line, text
+}
+
+func clock(ev models.Event) string {
+	return styleDim + ev.Timestamp.Local().Format("15:04:05") + styleReset
+}
+
+func formatOffset(d time.Duration) string {
+	d = d.Round(time.Second)
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	s := int(d.Seconds()) % 60
+	return fmt.Sprintf("%02d:%02d:%02d", h, m, s)
+}
+
+// fit truncates a line to width visible characters and clears the rest of the row.
+func fit(line string, width int) string {
+	return exporter.ClipLine(line, width) + styleReset + "\033[K"
+}
@@ -0,0 +1,401 @@
+package report
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/aggregator"
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+// DefaultDiffBucket is the width of the rows in a diff's relative timeline.
+const DefaultDiffBucket = 5 * time.Minute
+
+// maxBucketItems limits the activity listed per session in one timeline row.
+const maxBucketItems = 5
+
+// Presence values of diff entries.
+const (
+	InBoth  = "both"
+	OnlyInA = "a"
+	OnlyInB = "b"
+)
+
+// Diff compares two sessions, e.g. yesterday's attempt and today's fix, or two people
+// debugging the same issue. Files are compared relative to each session's project, and
+// the timeline aligns both sessions by the time elapsed since their start.
+type Diff struct {
+	A           DiffSide         `json:"a"`
+	B           DiffSide         `json:"b"`
+	Bucket      time.Duration    `json:"bucket"`
+	Files       []FileDiff       `json:"files"`       // Most combined focus time first
+	Commands    []CommandDiff    `json:"commands"`    // Most combined runs first
+	Diagnostics []DiagnosticDiff `json:"diagnostics"` // Error patterns, most combined occurrences first
+	Timeline    []DiffRow        `json:"timeline"`
+}
+
+// DiffSide summarizes one session of a diff.
+type DiffSide struct {
+	ID               string        `json:"id"`
+	ProjectPath      string        `json:"project_path"`
+	StartTime        time.Time     `json:"start_time"`
+	Duration         time.Duration `json:"duration"`
+	ActiveTime       time.Duration `json:"active_time"`
+	IdleTime         time.Duration `json:"idle_time"`
+	FlowTime         time.Duration `json:"flow_time"`
+	FocusRatio       float64       `json:"focus_ratio"`
+	Events           int           `json:"events"`
+	FileEdits        int           `json:"file_edits"`
+	Files            int           `json:"files"`
+	Commands         int           `json:"commands"`
+	FailedCommands   int           `json:"failed_commands"`
+	Diagnostics      int           `json:"diagnostics"`
+	ErrorCorrections int           `json:"error_corrections"`
+	Annotations      int           `json:"annotations"`
+}
+
+// FileDiff compares the time and edits spent in one file.
+type FileDiff struct {
+	File     string         `json:"file"` // Relative to the project when inside it
+	Presence string         `json:"presence"`
+	TimeA    time.Duration  `json:"time_a"`
+	TimeB    time.Duration  `json:"time_b"`
+	EditsA   int            `json:"edits_a"`
+	EditsB   int            `json:"edits_b"`
+	FirstA   *time.Duration `json:"first_a,omitempty"` // Offset of the first event in the file
+	FirstB   *time.Duration `json:"first_b,omitempty"`
+}
+
+// CommandDiff compares how often a command ran and failed.
+type CommandDiff struct {
+	Command  string `json:"command"`
+	Presence string `json:"presence"`
+	RunsA    int    `json:"runs_a"`
+	RunsB    int    `json:"runs_b"`
+	FailedA  int    `json:"failed_a"`
+	FailedB  int    `json:"failed_b"`
+}
+
+// DiagnosticDiff compares one error pattern: diagnostics of the same level whose
+// messages match once numbers and quoted names are masked.
+type DiagnosticDiff struct {
+	Pattern  string `json:"pattern"`
+	Level    string `json:"level"`
+	Presence string `json:"presence"`
+	CountA   int    `json:"count_a"`
+	CountB   int    `json:"count_b"`
+}
+
+// DiffRow lists what each session did during one bucket of elapsed time.
+type DiffRow struct {
+	Offset time.Duration `json:"offset"`
+	A      []string      `json:"a"`
+	B      []string      `json:"b"`
+}
+
+// BuildDiff compares two sessions. bucket sets the timeline row width; 0 means DefaultDiffBucket.
+func BuildDiff(a, b *models.Session, bucket time.Duration, config *aggregator.AggregatorConfig) *Diff {
+	if bucket <= 0 {
+		bucket = DefaultDiffBucket
+	}
+	agg := aggregator.New(config)
+	_, analyticsA := agg.AggregateSession(a)
+	_, analyticsB := agg.AggregateSession(b)
+
+	d := &Diff{
+		A:      diffSide(a, analyticsA),
+		B:      diffSide(b, analyticsB),
+		Bucket: bucket,
+	}
+	d.Files = diffFiles(a, b, analyticsA, analyticsB)
+	d.Commands = diffCommands(a, b)
+	d.Diagnostics = diffDiagnostics(a, b)
+	d.Timeline = diffTimeline(a, b, bucket)
+	return d
+}
+
+// diffSide summarizes a session the same way a Rollup counts it.
+func diffSide(session *models.Session, analytics *models.SessionAnalytics) DiffSide {
+	r := newRollup()
+	r.addSession(session, analytics)
+
+	files := make(map[string]bool)
+	for _, event := range session.Events {
+		if event.Type == "file_edit" && event.Data.Filename != "" {
+			files[event.Data.Filename] = true
+		}
+	}
+
+	return DiffSide{
+		ID:               session.ID,
+		ProjectPath:      session.ProjectPath,
+		StartTime:        session.StartTime,
+		Duration:         SessionDuration(session),
+		ActiveTime:       r.ActiveTime,
+		IdleTime:         r.IdleTime,
+		FlowTime:         r.FlowTime,
+		FocusRatio:       analytics.FocusRatio,
+		Events:           r.Events,
+		FileEdits:        r.FileEdits,
+		Files:            len(files),
+		Commands:         r.Commands,
+		FailedCommands:   r.FailedCommands,
+		Diagnostics:      r.Diagnostics,
+		ErrorCorrections: len(analytics.ErrorCorrections),
+		Annotations:      r.Annotations,
+	}
+}
+
+func diffFiles(a, b *models.Session, analyticsA, analyticsB *models.SessionAnalytics) []FileDiff {
+	index := make(map[string]*FileDiff)
+	entry := func(session *models.Session, file string) *FileDiff {
+		key := aggregator.RelativePath(session.ProjectPath, file)
+		if index[key] == nil {
+			index[key] = &FileDiff{File: key}
+		}
+		return index[key]
+	}
+
+	for file, seconds := range analyticsA.MainFiles {
+		entry(a, file).TimeA += time.Duration(seconds) * time.Second
+	}
+	for file, seconds := range analyticsB.MainFiles {
+		entry(b, file).TimeB += time.Duration(seconds) * time.Second
+	}
+	for _, event := range a.Events {
+		if event.Data.Filename == "" {
+			continue
+		}
+		f := entry(a, event.Data.Filename)
+		if event.Type == "file_edit" {
+			f.EditsA++
+		}
+		if f.FirstA == nil {
+			offset := event.Timestamp.Sub(a.StartTime)
+			f.FirstA = &offset
+		}
+	}
+	for _, event := range b.Events {
+		if event.Data.Filename == "" {
+			continue
+		}
+		f := entry(b, event.Data.Filename)
+		if event.Type == "file_edit" {
+			f.EditsB++
+		}
+		if f.FirstB == nil {
+			offset := event.Timestamp.Sub(b.StartTime)
+			f.FirstB = &offset
+		}
+	}
+
+	files := make([]FileDiff, 0, len(index))
+	for _, f := range index {
+		f.Presence = presence(f.FirstA != nil, f.FirstB != nil)
+		files = append(files, *f)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		ti, tj := files[i].TimeA+files[i].TimeB, files[j].TimeA+files[j].TimeB
+		if ti != tj {
+			return ti > tj
+		}
+		ei, ej := files[i].EditsA+files[i].EditsB, files[j].EditsA+files[j].EditsB
+		if ei != ej {
+			return ei > ej
+		}
+		return files[i].File < files[j].File
+	})
+	return files
+}
+
+func diffCommands(a, b *models.Session) []CommandDiff {
+	index := make(map[string]*CommandDiff)
+	count := func(session *models.Session, runs, failed func(*CommandDiff) *int) {
+		for _, event := range session.Events {
+			command := strings.TrimSpace(event.Data.Command)
+			if event.Type != "terminal_command" || command == "" {
+				continue
+			}
+			if index[command] == nil {
+				index[command] = &CommandDiff{Command: command}
+			}
+			*runs(index[command])++
+			if IsFailedCommand(event) {
+				*failed(index[command])++
+			}
+		}
+	}
+	count(a, func(c *CommandDiff) *int { return &c.RunsA }, func(c *CommandDiff) *int { return &c.FailedA })
+	count(b, func(c *CommandDiff) *int { return &c.RunsB }, func(c *CommandDiff) *int { return &c.FailedB })
+
+	commands := make([]CommandDiff, 0, len(index))
+	for _, c := range index {
+		c.Presence = presence(c.RunsA > 0, c.RunsB > 0)
+		commands = append(commands, *c)
+	}
+	sort.Slice(commands, func(i, j int) bool {
+		ri, rj := commands[i].RunsA+commands[i].RunsB, commands[j].RunsA+commands[j].RunsB
+		if ri != rj {
+			return ri > rj
+		}
+		return commands[i].Command < commands[j].Command
+	})
+	return commands
+}
+
+func diffDiagnostics(a, b *models.Session) []DiagnosticDiff {
+	index := make(map[string]*DiagnosticDiff)
+	count := func(session *models.Session, counter func(*DiagnosticDiff) *int) {
+		for _, event := range session.Events {
+			if event.Type != "lsp_diagnostic" || strings.TrimSpace(event.Data.Message) == "" {
+				continue
+			}
+			pattern := DiagnosticPattern(event.Data.Message)
+			key := event.Data.Level + "\x00" + pattern
+			if index[key] == nil {
+				index[key] = &DiagnosticDiff{Pattern: pattern, Level: event.Data.Level}
+			}
+			*counter(index[key])++
+		}
+	}
+	count(a, func(d *DiagnosticDiff) *int { return &d.CountA })
+	count(b, func(d *DiagnosticDiff) *int { return &d.CountB })
+
+	diagnostics := make([]DiagnosticDiff, 0, len(index))
+	for _, d := range index {
+		d.Presence = presence(d.CountA > 0, d.CountB > 0)
+		diagnostics = append(diagnostics, *d)
+	}
+	sort.Slice(diagnostics, func(i, j int) bool {
+		ci, cj := diagnostics[i].CountA+diagnostics[i].CountB, diagnostics[j].CountA+diagnostics[j].CountB
+		if ci != cj {
+			return ci > cj
+		}
+		return diagnostics[i].Pattern < diagnostics[j].Pattern
+	})
+	return diagnostics
+}
+
+var (
+	quotedPattern = regexp.MustCompile("'[^']*'|\"[^\"]*\"|`[^`]*`")
+	numberPattern = regexp.MustCompile(`\b\d+\b`)
+)
+
+// DiagnosticPattern masks quoted names and numbers in a diagnostic message, so messages that
+// differ only in identifiers or positions are counted as the same error.
+func DiagnosticPattern(message string) string {
+	pattern := quotedPattern.ReplaceAllString(strings.Join(strings.Fields(message), " "), "'…'")
+	return numberPattern.ReplaceAllString(pattern, "N")
+}
+
+// diffTimeline buckets each session's edits, commands, diagnostics and notes by the time
+// elapsed since its start and returns the buckets in which either session did something.
+func diffTimeline(a, b *models.Session, bucket time.Duration) []DiffRow {
+	itemsA := timelineItems(a, bucket)
+	itemsB := timelineItems(b, bucket)
+
+	var slots []int
+	seen := make(map[int]bool)
+	for _, items := range []map[int][]string{itemsA, itemsB} {
+		for slot := range items {
+			if !seen[slot] {
+				seen[slot] = true
+				slots = append(slots, slot)
+			}
+		}
+	}
+	sort.Ints(slots)
+
+	rows := make([]DiffRow, 0, len(slots))
+	for _, slot := range slots {
+		rows = append(rows, DiffRow{
+			Offset: time.Duration(slot) * bucket,
+			A:      limitItems(itemsA[slot]),
+			B:      limitItems(itemsB[slot]),
+		})
+	}
+	return rows
+}
+
+// timelineItems describes a session's activity per bucket: edited files with their edit
+// counts, commands with failures marked, new error patterns and notes, in time order.
+func timelineItems(session *models.Session, bucket time.Duration) map[int][]string {
+	items := make(map[int][]string)
+	edits := make(map[int]map[string]int)
+	seenPatterns := make(map[string]bool)
+
+	for _, event := range session.Events {
+		offset := event.Timestamp.Sub(session.StartTime)
+		if offset < 0 {
+			offset = 0
+		}
+		slot := int(offset / bucket)
+
+		switch event.Type {
+		case "file_edit":
+			if event.Data.Filename == "" {
+				continue
+			}
+			file := aggregator.RelativePath(session.ProjectPath, event.Data.Filename)
+			if edits[slot] == nil {
+				edits[slot] = make(map[string]int)
+			}
+			if edits[slot][file] == 0 {
+				// Placeholder keeps the file's position; the count is filled in below
+				items[slot] = append(items[slot], "\x00"+file)
+			}
+			edits[slot][file]++
+		case "terminal_command":
+			item := "$ " + strings.Join(strings.Fields(event.Data.Command), " ")
+			if IsFailedCommand(event) {
+				item += fmt.Sprintf(" (exit %d)", *event.Data.ExitCode)
+			}
+			items[slot] = append(items[slot], item)
+		case "lsp_diagnostic":
+			pattern := DiagnosticPattern(event.Data.Message)
+			if pattern == "" || seenPatterns[pattern] {
+				continue
+			}
+			seenPatterns[pattern] = true
+			items[slot] = append(items[slot], strings.ToLower(event.Data.Level)+": "+pattern)
+		case "annotation":
+			if note := strings.Join(strings.Fields(event.Data.Note), " "); note != "" {
+				items[slot] = append(items[slot], "note: "+note)
+			}
+		}
+	}
+
+	for slot, list := range items {
+		for i, item := range list {
+			if file, ok := strings.CutPrefix(item, "\x00"); ok {
+				list[i] = fmt.Sprintf("edit %s ×%d", file, edits[slot][file])
+			}
+		}
+	}
+	return items
+}
+
+// limitItems keeps the first maxBucketItems entries and counts the rest.
+func limitItems(items []string) []string {
+	if items == nil {
+		return []string{}
+	}
+	if len(items) <= maxBucketItems {
+		return items
+	}
+	return append(items[:maxBucketItems:maxBucketItems], fmt.Sprintf("… %d more", len(items)-maxBucketItems))
+}
+
+func presence(inA, inB bool) string {
+	switch {
+	case inA && inB:
+		return InBoth
+	case inA:
+		return OnlyInA
+	default:
+		return OnlyInB
+	}
+}
@@ -0,0 +1,329 @@
+// Package report rolls up events and analytics across many sessions into range reports.
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/aggregator"
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+// Grouping keys accepted by Build.
+const (
+	GroupByDay     = "day"
+	GroupByWeek    = "week"
+	GroupByProject = "project"
+	GroupByFile    = "file"
+)
+
+// topN limits the ranked lists in a rollup.
+const topN = 10
+
+// Report is a rollup of many sessions over a time range.
+type Report struct {
+	Since            *time.Time        `json:"since,omitempty"` // nil when unbounded
+	Until            *time.Time        `json:"until,omitempty"` // Exclusive; nil when unbounded
+	Project          string            `json:"project,omitempty"`
+	GroupBy          string            `json:"group_by"`
+	Totals           Rollup            `json:"totals"`
+	Groups           []Group           `json:"groups"`
+	DiagnosticsTrend []DiagnosticPoint `json:"diagnostics_trend"`
+}
+
+// Group is the rollup for one day, week, project or file.
+type Group struct {
+	Key string `json:"key"`
+	Rollup
+}
+
+// Rollup holds aggregated totals for a set of sessions.
+type Rollup struct {
+	Sessions       int             `json:"sessions"`
+	Events         int             `json:"events"`
+	ActiveTime     time.Duration   `json:"active_time"`
+	IdleTime       time.Duration   `json:"idle_time"`
+	FlowTime       time.Duration   `json:"flow_time"`
+	FileEdits      int             `json:"file_edits"`
+	Commands       int             `json:"commands"`
+	FailedCommands int             `json:"failed_commands"`
+	Annotations    int             `json:"annotations"`
+	Diagnostics    int             `json:"diagnostics"`
+	TopFiles       []FileTime      `json:"top_files"`
+	TopFailing     []CommandCount  `json:"top_failing_commands"`
+	files          map[string]int  // File -> focus seconds
+	edits          map[string]int  // File -> file_edit events
+	failing        map[string]int  // Command -> failure count
+	sessionIDs     map[string]bool // Sessions counted in this rollup
+}
+
+// FileTime is the focus time spent in a file.
+type FileTime struct {
+	File    string        `json:"file"`
+	Time    time.Duration `json:"time"`
+	Edits   int           `json:"edits"`
+	Percent float64       `json:"percent"`
+}
+
+// CommandCount counts how often a command failed.
+type CommandCount struct {
+	Command  string `json:"command"`
+	Failures int    `json:"failures"`
+}
+
+// DiagnosticPoint counts LSP diagnostics recorded on one day.
+type DiagnosticPoint struct {
+	Day      string `json:"day"`
+	Errors   int    `json:"errors"`
+	Warnings int    `json:"warnings"`
+	Other    int    `json:"other"`
+}
+
+// Build aggregates the sessions into a report grouped by day, week, project or file.
+func Build(sessions []*models.Session, groupBy string, config *aggregator.AggregatorConfig) (*Report, error) {
+	switch groupBy {
+	case GroupByDay, GroupByWeek, GroupByProject, GroupByFile:
+	case "":
+		groupBy = GroupByDay
+	default:
+		return nil, fmt.Errorf("unknown group-by %q: use day, week, project or file", groupBy)
+	}
+
+	agg := aggregator.New(config)
+	report := &Report{GroupBy: groupBy, Totals: newRollup()}
+	groups := make(map[string]*Rollup)
+	trend := make(map[string]*DiagnosticPoint)
+
+	group := func(key string) *Rollup {
+		if groups[key] == nil {
+			r := newRollup()
+			groups[key] = &r
+		}
+		return groups[key]
+	}
+
+	for _, session := range sessions {
+		_, analytics := agg.AggregateSession(session)
+
+		report.Totals.addSession(session, analytics)
+
+		switch groupBy {
+		case GroupByFile:
+			addFileGroups(session, analytics, group)
+		default:
+			group(sessionKey(session, groupBy)).addSession(session, analytics)
+		}
+
+		for _, event := range session.Events {
+			if event.Type != "lsp_diagnostic" {
+				continue
+			}
+			day := event.Timestamp.Local().Format("2006-01-02")
+			point := trend[day]
+			if point == nil {
+				point = &DiagnosticPoint{Day: day}
+				trend[day] = point
+			}
+			switch strings.ToLower(event.Data.Level) {
+			case "error":
+				point.Errors++
+			case "warning", "warn":
+				point.Warnings++
+			default:
+				point.Other++
+			}
+		}
+	}
+
+	report.Totals.finish()
+	for key, rollup := range groups {
+		rollup.finish()
+		report.Groups = append(report.Groups, Group{Key: key, Rollup: *rollup})
+	}
+	sort.Slice(report.Groups, func(i, j int) bool {
+		if groupBy == GroupByFile && report.Groups[i].ActiveTime != report.Groups[j].ActiveTime {
+			return report.Groups[i].ActiveTime > report.Groups[j].ActiveTime
+		}
+		return report.Groups[i].Key < report.Groups[j].Key
+	})
+
+	for _, point := range trend {
+		report.DiagnosticsTrend = append(report.DiagnosticsTrend, *point)
+	}
+	sort.Slice(report.DiagnosticsTrend, func(i, j int) bool {
+		return report.DiagnosticsTrend[i].Day < report.DiagnosticsTrend[j].Day
+	})
+
+	return report, nil
+}
+
+// SessionDuration returns how long a session ran, using its last event for unfinished sessions.
+func SessionDuration(session *models.Session) time.Duration {
+	end := session.EndTime
+	if end.IsZero() && len(session.Events) > 0 {
+		end = session.Events[len(session.Events)-1].Timestamp
+	}
+	if end.Before(session.StartTime) {
+		return 0
+	}
+	return end.Sub(session.StartTime)
+}
+
+// FlowTime sums the duration of the session's flow state blocks.
+func FlowTime(analytics *models.SessionAnalytics) time.Duration {
+	var total time.Duration
+	for _, block := range analytics.FlowBlocks {
+		total += block.Duration
+	}
+	return total
+}
+
+// BreakTime sums the breaks between merged sessions (intervals after session_gap markers).
+func BreakTime(session *models.Session) time.Duration {
+	var total time.Duration
+	for i := 1; i < len(session.Events); i++ {
+		if session.Events[i-1].Type == "session_gap" {
+			total += session.Events[i].Timestamp.Sub(session.Events[i-1].Timestamp)
+		}
+	}
+	return total
+}
+
+// IsFailedCommand reports whether a terminal_command event exited with a non-zero status.
+func IsFailedCommand(event models.Event) bool {
+	return event.Type == "terminal_command" && event.Data.ExitCode != nil && *event.Data.ExitCode != 0
+}
+
+// sessionKey returns the group a session belongs to for day, week and project grouping.
+func sessionKey(session *models.Session, groupBy string) string {
+	start := session.StartTime.Local()
+	switch groupBy {
+	case GroupByWeek:
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case GroupByProject:
+		return session.ProjectPath
+	default:
+		return start.Format("2006-01-02")
+	}
+}
+
+// addFileGroups attributes a session's per-file focus time, edits and diagnostics to file groups.
+func addFileGroups(session *models.Session, analytics *models.SessionAnalytics, group func(string) *Rollup) {
+	for file, seconds := range analytics.MainFiles {
+		r := group(file)
+		r.ActiveTime += time.Duration(seconds) * time.Second
+		r.files[file] += seconds
+		r.countSession(session.ID)
+	}
+	for _, event := range session.Events {
+		if event.Data.Filename == "" {
+			continue
+		}
+		switch event.Type {
+		case "file_edit":
+			r := group(event.Data.Filename)
+			r.FileEdits++
+			r.edits[event.Data.Filename]++
+			r.countSession(session.ID)
+		case "lsp_diagnostic":
+			r := group(event.Data.Filename)
+			r.Diagnostics++
+			r.countSession(session.ID)
+		}
+	}
+}
+
+func newRollup() Rollup {
+	return Rollup{
+		files:      make(map[string]int),
+		edits:      make(map[string]int),
+		failing:    make(map[string]int),
+		sessionIDs: make(map[string]bool),
+	}
+}
+
+// countSession records that a session contributed to the rollup.
+func (r *Rollup) countSession(id string) {
+	if !r.sessionIDs[id] {
+		r.sessionIDs[id] = true
+		r.Sessions++
+	}
+}
+
+// addSession adds one session's events and analytics to the rollup.
+func (r *Rollup) addSession(session *models.Session, analytics *models.SessionAnalytics) {
+	r.countSession(session.ID)
+	r.Events += len(session.Events)
+
+	active := SessionDuration(session) - analytics.TotalIdleTime - BreakTime(session)
+	if active > 0 {
+		r.ActiveTime += active
+	}
+	r.IdleTime += analytics.TotalIdleTime
+	r.FlowTime += FlowTime(analytics)
+
+	for file, seconds := range analytics.MainFiles {
+		r.files[file] += seconds
+	}
+
+	for _, event := range session.Events {
+		switch event.Type {
+		case "file_edit":
+			r.FileEdits++
+			r.edits[event.Data.Filename]++
+		case "terminal_command":
+			r.Commands++
+			if IsFailedCommand(event) {
+				r.FailedCommands++
+				r.failing[event.Data.Command]++
+			}
+		case "annotation":
+			r.Annotations++
+		case "lsp_diagnostic":
+			r.Diagnostics++
+		}
+	}
+}
+
+// finish turns the accumulated maps into ranked top lists.
+func (r *Rollup) finish() {
+	var total int
+	for _, seconds := range r.files {
+		total += seconds
+	}
+
+	r.TopFiles = nil
+	for file, seconds := range r.files {
+		ft := FileTime{File: file, Time: time.Duration(seconds) * time.Second, Edits: r.edits[file]}
+		if total > 0 {
+			ft.Percent = float64(seconds) / float64(total) * 100
+		}
+		r.TopFiles = append(r.TopFiles, ft)
+	}
+	sort.Slice(r.TopFiles, func(i, j int) bool {
+		if r.TopFiles[i].Time != r.TopFiles[j].Time {
+			return r.TopFiles[i].Time > r.TopFiles[j].Time
+		}
+		return r.TopFiles[i].File < r.TopFiles[j].File
+	})
+	if len(r.TopFiles) > topN {
+		r.TopFiles = r.TopFiles[:topN]
+	}
+
+	r.TopFailing = nil
+	for command, failures := range r.failing {
+		r.TopFailing = append(r.TopFailing, CommandCount{Command: command, Failures: failures})
+	}
+	sort.Slice(r.TopFailing, func(i, j int) bool {
+		if r.TopFailing[i].Failures != r.TopFailing[j].Failures {
+			return r.TopFailing[i].Failures > r.TopFailing[j].Failures
+		}
+		return r.TopFailing[i].Command < r.TopFailing[j].Command
+	})
+	if len(r.TopFailing) > topN {
+		r.TopFailing = r.TopFailing[:topN]
+	}
+}
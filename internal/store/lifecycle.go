@@ -0,0 +1,306 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/andev0x/capytrace.nvim/internal/aggregator"
+	"github.com/andev0x/capytrace.nvim/internal/anonymize"
+	"github.com/andev0x/capytrace.nvim/internal/exporter"
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+// ArchiveDirName is the subdirectory of the save path that holds archived sessions.
+const ArchiveDirName = "archive"
+
+// ArchiveDir returns the archive directory for a save path.
+func ArchiveDir(savePath string) string {
+	return filepath.Join(savePath, ArchiveDirName)
+}
+
+// Stat loads a session and describes its artifacts.
+func Stat(savePath, sessionID string) (Info, error) {
+	if err := ValidateID(sessionID); err != nil {
+		return Info{}, err
+	}
+	session, err := Read(savePath, sessionID)
+	if err != nil {
+		return Info{}, err
+	}
+	return describe(savePath, session), nil
+}
+
+// Write persists a session as {id}_raw.json in the save path.
+// IDs that are not a plain file name are rejected, see ValidateID.
+func Write(savePath string, session *models.Session) error {
+	if err := ValidateID(session.ID); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(savePath, session.ID+"_raw.json"), data, 0644)
+}
+
+// Exists reports whether a session with the given ID is stored in the save path.
+func Exists(savePath, sessionID string) bool {
+	_, err := Read(savePath, sessionID)
+	return err == nil
+}
+
+// Delete removes every artifact of a session along with its SQLite rows.
+func Delete(info Info) error {
+	if err := ValidateID(info.ID); err != nil {
+		return err
+	}
+	if err := RemoveFiles(info); err != nil {
+		return err
+	}
+	return database().DeleteSession(info.ID)
+}
+
+// Rename changes a session's ID, rewriting the raw JSON, regenerating the exports of
+// its output formats and moving its SQLite rows and daily-note section, so no stale
+// references to the old name remain.
+func Rename(savePath, oldID, newID string) error {
+	if err := ValidateID(oldID); err != nil {
+		return err
+	}
+	if err := ValidateID(newID); err != nil {
+		return err
+	}
+	if Exists(savePath, newID) {
+		return fmt.Errorf("session %s already exists", newID)
+	}
+
+	info, err := Stat(savePath, oldID)
+	if err != nil {
+		return err
+	}
+	session, err := Read(savePath, oldID)
+	if err != nil {
+		return err
+	}
+
+	if exporter.HasFormat(session.OutputFormat, "notes") && !anonymize.Enabled(session.ExportOptions) {
+		if err := exporter.NewNotesExporter(aggregator.DefaultConfig()).Remove(session, savePath); err != nil {
+			return err
+		}
+	}
+	// Renamed first, so the sqlite format below updates the renamed rows
+	if err := database().RenameSession(oldID, newID); err != nil {
+		return err
+	}
+
+	session.ID = newID
+	if err := writeWithExports(savePath, session, info, true); err != nil {
+		_ = database().RenameSession(newID, oldID)
+		return err
+	}
+
+	summaryPath := filepath.Join(savePath, SummaryFile)
+	for _, path := range info.Files {
+		if path == summaryPath {
+			continue // regenerated for the new ID above
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// Copy duplicates a session under a new ID together with the exports of its output formats.
+func Copy(savePath, srcID, dstID string) error {
+	if err := ValidateID(srcID); err != nil {
+		return err
+	}
+	if err := ValidateID(dstID); err != nil {
+		return err
+	}
+	if Exists(savePath, dstID) {
+		return fmt.Errorf("session %s already exists", dstID)
+	}
+
+	info, err := Stat(savePath, srcID)
+	if err != nil {
+		return err
+	}
+	session, err := Read(savePath, srcID)
+	if err != nil {
+		return err
+	}
+
+	session.ID = dstID
+	if err := writeWithExports(savePath, session, info, false); err != nil {
+		return err
+	}
+
+	db := database()
+	inDB, err := db.HasSession(srcID)
+	if err != nil {
+		return err
+	}
+	if inDB {
+		return db.Export(session, savePath)
+	}
+	return nil
+}
+
+// Archive moves a session's artifacts into the archive directory and drops its
+// SQLite rows, hiding it from list and stats without deleting anything.
+func Archive(savePath, sessionID string) error {
+	if err := ValidateID(sessionID); err != nil {
+		return err
+	}
+	info, err := Stat(savePath, sessionID)
+	if err != nil {
+		return err
+	}
+
+	archiveDir := ArchiveDir(savePath)
+	if err := os.MkdirAll(archiveDir, 0755); err != nil {
+		return err
+	}
+
+	for _, path := range info.Files {
+		if err := os.Rename(path, filepath.Join(archiveDir, filepath.Base(path))); err != nil {
+			return fmt.Errorf("failed to archive %s: %w", path, err)
+		}
+	}
+
+	return database().DeleteSession(sessionID)
+}
+
+// Unarchive moves an archived session back into the save path and restores its
+// SQLite rows when the session exports to SQLite.
+func Unarchive(savePath, sessionID string) error {
+	if err := ValidateID(sessionID); err != nil {
+		return err
+	}
+	if Exists(savePath, sessionID) {
+		return fmt.Errorf("session %s already exists", sessionID)
+	}
+
+	archiveDir := ArchiveDir(savePath)
+	session, err := Read(archiveDir, sessionID)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range Artifacts(archiveDir, sessionID) {
+		dest := filepath.Join(savePath, filepath.Base(path))
+		if filepath.Base(path) == SummaryFile {
+			if _, err := os.Stat(dest); err == nil {
+				// A newer session owns the live summary; drop the archived copy
+				_ = os.Remove(path)
+				continue
+			}
+		}
+		if err := os.Rename(path, dest); err != nil {
+			return fmt.Errorf("failed to unarchive %s: %w", path, err)
+		}
+	}
+
+	if exporter.HasFormat(session.OutputFormat, "sqlite") {
+		return database().Export(session, savePath)
+	}
+	return nil
+}
+
+// writeWithExports writes a session's raw JSON and runs the exporters of its output
+// formats with its export options, as the end of a recording does. SESSION_SUMMARY.md is
+// regenerated too when withSummary is set and src owned it.
+func writeWithExports(savePath string, session *models.Session, src Info, withSummary bool) error {
+	if err := Write(savePath, session); err != nil {
+		return err
+	}
+
+	if _, err := exporter.Collect(exporter.Run(session, savePath, DataDir())); err != nil {
+		return err
+	}
+
+	if withSummary {
+		for _, path := range src.Files {
+			if filepath.Base(path) == SummaryFile {
+				return exporter.NewSmartMarkdownExporter(aggregator.DefaultConfig()).Export(session, savePath)
+			}
+		}
+	}
+	return nil
+}
+
+// database returns the exporter for the shared SQLite database.
+func database() *exporter.SQLiteExporter {
+	return exporter.NewSQLiteExporter(DataDir())
+}
+
+// Import installs a session that was recorded elsewhere, together with its exports keyed
+// by file name, and records it in SQLite. When the ID is already taken in the save path,
+// the archive or the database, the session is stored as {id}-imported (then -imported-2,
+// ...) and the exports are regenerated for the new ID. It returns the installed ID, and
+// rejects IDs that ValidateID refuses.
+func Import(savePath string, session *models.Session, files map[string][]byte) (string, error) {
+	if err := ValidateID(session.ID); err != nil {
+		return "", err
+	}
+	for _, dir := range []string{savePath, DataDir()} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", err
+		}
+	}
+
+	db := database()
+	taken := func(id string) (bool, error) {
+		if Exists(savePath, id) || Exists(ArchiveDir(savePath), id) {
+			return true, nil
+		}
+		return db.HasSession(id)
+	}
+
+	srcID := session.ID
+	collides, err := taken(srcID)
+	if err != nil {
+		return "", err
+	}
+
+	if collides {
+		base := srcID + "-imported"
+		id := base
+		for n := 2; ; n++ {
+			if collides, err = taken(id); err != nil {
+				return "", err
+			} else if !collides {
+				break
+			}
+			id = fmt.Sprintf("%s-%d", base, n)
+		}
+
+		src := Info{ID: srcID}
+		for name := range files {
+			src.Files = append(src.Files, name)
+		}
+		session.ID = id
+		if err := writeWithExports(savePath, session, src, false); err != nil {
+			return "", err
+		}
+	} else {
+		if err := Write(savePath, session); err != nil {
+			return "", err
+		}
+		for name, data := range files {
+			// The summary belongs to whichever session in this save path is newest
+			if name == SummaryFile || name == srcID+"_raw.json" || name == srcID+".json" {
+				continue
+			}
+			if err := os.WriteFile(filepath.Join(savePath, name), data, 0644); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return session.ID, db.Export(session, savePath)
+}
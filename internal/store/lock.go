@@ -0,0 +1,58 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// lockPath returns the path of the lock file marking a session as held by a process.
+func lockPath(savePath, sessionID string) string {
+	return filepath.Join(savePath, sessionID+".lock")
+}
+
+// WriteLock records that the current process is recording the session.
+func WriteLock(savePath, sessionID string) error {
+	if err := ValidateID(sessionID); err != nil {
+		return err
+	}
+	return os.WriteFile(lockPath(savePath, sessionID), []byte(strconv.Itoa(os.Getpid())), 0644)
+}
+
+// RemoveLock clears the lock written by WriteLock.
+func RemoveLock(savePath, sessionID string) {
+	if ValidateID(sessionID) != nil {
+		return
+	}
+	_ = os.Remove(lockPath(savePath, sessionID))
+}
+
+// Held reports whether another running process holds the session lock.
+// Stale locks left behind by exited processes are ignored.
+func Held(savePath, sessionID string) bool {
+	if ValidateID(sessionID) != nil {
+		return false
+	}
+	data, err := os.ReadFile(lockPath(savePath, sessionID))
+	if err != nil {
+		return false
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 || pid == os.Getpid() {
+		return false
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		// FindProcess only succeeds for live processes on Windows
+		return true
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
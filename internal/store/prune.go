@@ -0,0 +1,184 @@
+package store
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// PrunePolicy describes which sessions are eligible for removal.
+// Age and count rules combine like backup retention: a session is pruned only when
+// it falls outside every keep rule that is set. MaxTotalSize is applied afterwards,
+// pruning the oldest remaining sessions until the save path fits.
+type PrunePolicy struct {
+	// OlderThan prunes sessions that started more than this long ago (0 disables)
+	OlderThan time.Duration
+
+	// KeepLast keeps the N most recent sessions per project (0 disables)
+	KeepLast int
+
+	// MaxTotalSize caps the combined artifact size in bytes (0 disables)
+	MaxTotalSize int64
+
+	// KeepTagged protects sessions with at least one #tag in their annotations
+	KeepTagged bool
+
+	// KeepAnnotated protects sessions with any annotation
+	KeepAnnotated bool
+}
+
+// Enabled reports whether the policy would select anything at all.
+func (p PrunePolicy) Enabled() bool {
+	return p.OlderThan > 0 || p.KeepLast > 0 || p.MaxTotalSize > 0
+}
+
+// SelectPrunable returns the sessions that the policy would remove, oldest first.
+// Active sessions are never selected.
+func SelectPrunable(infos []Info, policy PrunePolicy, now time.Time) []Info {
+	if !policy.Enabled() {
+		return nil
+	}
+
+	protected := make(map[string]bool)
+	for _, info := range infos {
+		if info.Active ||
+			(policy.KeepTagged && len(info.Tags) > 0) ||
+			(policy.KeepAnnotated && info.Annotated) {
+			protected[info.ID] = true
+		}
+	}
+
+	recent := make(map[string]bool)
+	if policy.KeepLast > 0 {
+		byProject := make(map[string][]Info)
+		for _, info := range infos {
+			byProject[info.ProjectPath] = append(byProject[info.ProjectPath], info)
+		}
+		for _, sessions := range byProject {
+			sort.Slice(sessions, func(i, j int) bool {
+				return sessions[i].StartTime.After(sessions[j].StartTime)
+			})
+			for i := 0; i < len(sessions) && i < policy.KeepLast; i++ {
+				recent[sessions[i].ID] = true
+			}
+		}
+	}
+
+	selected := make(map[string]bool)
+	if policy.OlderThan > 0 || policy.KeepLast > 0 {
+		cutoff := now.Add(-policy.OlderThan)
+		for _, info := range infos {
+			if protected[info.ID] {
+				continue
+			}
+			if policy.OlderThan > 0 && !info.StartTime.Before(cutoff) {
+				continue
+			}
+			if policy.KeepLast > 0 && recent[info.ID] {
+				continue
+			}
+			selected[info.ID] = true
+		}
+	}
+
+	if policy.MaxTotalSize > 0 {
+		var total int64
+		for _, info := range infos {
+			if !selected[info.ID] {
+				total += info.Size
+			}
+		}
+
+		// infos is sorted oldest first, so the oldest sessions go first
+		for _, info := range infos {
+			if total <= policy.MaxTotalSize {
+				break
+			}
+			if selected[info.ID] || protected[info.ID] {
+				continue
+			}
+			selected[info.ID] = true
+			total -= info.Size
+		}
+	}
+
+	var result []Info
+	for _, info := range infos {
+		if selected[info.ID] {
+			result = append(result, info)
+		}
+	}
+	return result
+}
+
+// RemoveFiles deletes every artifact file that belongs to a session.
+func RemoveFiles(info Info) error {
+	for _, path := range info.Files {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// WriteArchive writes the artifacts of the given sessions into a gzip-compressed tarball.
+// Files are stored by base name so the archive can be extracted straight back into a save path.
+func WriteArchive(archivePath string, infos []Info) error {
+	f, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	for _, info := range infos {
+		for _, path := range info.Files {
+			if err := addToArchive(tw, path); err != nil {
+				_ = f.Close()
+				return fmt.Errorf("failed to archive %s: %w", path, err)
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// addToArchive copies a single file into the tar stream.
+func addToArchive(tw *tar.Writer, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+
+	stat, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	header, err := tar.FileInfoHeader(stat, "")
+	if err != nil {
+		return err
+	}
+	header.Name = filepath.Base(path)
+
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, src)
+	return err
+}
@@ -0,0 +1,254 @@
+// Package store locates and manages the on-disk artifacts that make up a session.
+// A single session is spread across several files in the save path (raw JSON, exports,
+// Markdown reports) plus rows in the shared SQLite database; this package treats them as a unit.
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/aggregator"
+	"github.com/andev0x/capytrace.nvim/internal/exporter"
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+// SummaryFile is the shared aggregated report regenerated for the most recent session.
+const SummaryFile = "SESSION_SUMMARY.md"
+
+// Info describes a stored session and the files that belong to it.
+type Info struct {
+	ID          string
+	ProjectPath string
+	StartTime   time.Time
+	EndTime     time.Time
+	Active      bool
+	Annotated   bool
+	Tags        []string
+	Files       []string // Every artifact in the save path, joined onto the save path
+	Size        int64    // Combined size of Files in bytes
+}
+
+// DataDir returns the directory holding the shared SQLite database (~/.local/share/capytrace).
+func DataDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".local", "share", "capytrace")
+}
+
+// ValidateID rejects session IDs that cannot be used as a file name prefix inside the
+// save path: empty IDs and IDs containing path separators or "..".
+func ValidateID(id string) error {
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.Contains(id, "..") || filepath.Base(id) != id {
+		return fmt.Errorf("invalid session ID %q", id)
+	}
+	return nil
+}
+
+// Read loads the raw session JSON without registering it as active.
+// Both {id}_raw.json and the legacy {id}.json naming schemes are supported.
+func Read(savePath, sessionID string) (*models.Session, error) {
+	if err := ValidateID(sessionID); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(savePath, sessionID+"_raw.json"))
+	if err != nil {
+		data, err = os.ReadFile(filepath.Join(savePath, sessionID+".json"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var session models.Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// artifactSuffixes lists the raw JSON names and every exporter's artifact suffixes,
+// plugins included. Plugin manifests are read once per process.
+var artifactSuffixes = sync.OnceValue(func() []string {
+	suffixes := []string{"_raw.json", ".json"}
+	for _, suffix := range exporter.ArtifactSuffixes(true) {
+		if !slices.Contains(suffixes, suffix) {
+			suffixes = append(suffixes, suffix)
+		}
+	}
+	return suffixes
+})
+
+// SessionFileID reports the session ID stored in a file of the save path: {id}_raw.json,
+// or the legacy {id}.json unless the name ends in another exporter's JSON artifact
+// suffix such as _export.json or .wakatime.json.
+func SessionFileID(name string) (string, bool) {
+	if id, ok := strings.CutSuffix(name, "_raw.json"); ok {
+		return id, ValidateID(id) == nil
+	}
+	id, ok := strings.CutSuffix(name, ".json")
+	if !ok {
+		return "", false
+	}
+	for _, suffix := range artifactSuffixes() {
+		if suffix != ".json" && suffix != "_raw.json" && strings.HasSuffix(name, suffix) {
+			return "", false
+		}
+	}
+	return id, ValidateID(id) == nil
+}
+
+// Artifacts returns the paths of all existing files that belong to a session: the raw
+// JSON and the files named by the exporter manifests. SESSION_SUMMARY.md is only
+// included when it was last generated for this session.
+func Artifacts(savePath, sessionID string) []string {
+	var paths []string
+	for _, suffix := range artifactSuffixes() {
+		path := filepath.Join(savePath, sessionID+suffix)
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, path)
+		}
+	}
+
+	if SummaryOwner(savePath) == sessionID {
+		paths = append(paths, filepath.Join(savePath, SummaryFile))
+	}
+
+	return paths
+}
+
+// SummaryOwner returns the session ID that SESSION_SUMMARY.md currently describes, or "".
+func SummaryOwner(savePath string) string {
+	data, err := os.ReadFile(filepath.Join(savePath, SummaryFile))
+	if err != nil {
+		return ""
+	}
+
+	marker := []byte("**Session ID:** `")
+	idx := bytes.Index(data, marker)
+	if idx < 0 {
+		return ""
+	}
+	rest := data[idx+len(marker):]
+	end := bytes.IndexByte(rest, '`')
+	if end < 0 {
+		return ""
+	}
+	return string(rest[:end])
+}
+
+// Query selects sessions by start time and project. Zero fields match everything.
+type Query struct {
+	Since   time.Time
+	Until   time.Time
+	Project string // Full project path or its base name
+}
+
+// Match reports whether a session falls inside the query.
+func (q Query) Match(session *models.Session) bool {
+	if !q.Since.IsZero() && session.StartTime.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !session.StartTime.Before(q.Until) {
+		return false
+	}
+	if q.Project != "" && session.ProjectPath != q.Project && filepath.Base(session.ProjectPath) != q.Project {
+		return false
+	}
+	return true
+}
+
+// Scan reads every session in the save path and returns them sorted by start time (oldest first).
+// Sessions whose JSON cannot be parsed are skipped.
+func Scan(savePath string) ([]Info, error) {
+	sessions, err := readAll(savePath)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]Info, 0, len(sessions))
+	for _, session := range sessions {
+		infos = append(infos, describe(savePath, session))
+	}
+	return infos, nil
+}
+
+// LoadRange reads the sessions matching the query, sorted by start time (oldest first).
+func LoadRange(savePath string, q Query) ([]*models.Session, error) {
+	sessions, err := readAll(savePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []*models.Session
+	for _, session := range sessions {
+		if q.Match(session) {
+			matched = append(matched, session)
+		}
+	}
+	return matched, nil
+}
+
+// readAll loads every parseable session in the save path, oldest first.
+func readAll(savePath string) ([]*models.Session, error) {
+	entries, err := os.ReadDir(savePath)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var sessions []*models.Session
+
+	for _, entry := range entries {
+		sessionID, ok := SessionFileID(entry.Name())
+		if !ok || seen[sessionID] {
+			continue
+		}
+		seen[sessionID] = true
+
+		session, err := Read(savePath, sessionID)
+		if err != nil {
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].StartTime.Before(sessions[j].StartTime)
+	})
+
+	return sessions, nil
+}
+
+// describe builds an Info for a loaded session.
+func describe(savePath string, session *models.Session) Info {
+	info := Info{
+		ID:          session.ID,
+		ProjectPath: session.ProjectPath,
+		StartTime:   session.StartTime,
+		EndTime:     session.EndTime,
+		Active:      session.Active,
+		Tags:        aggregator.ExtractTags(session.Events),
+		Files:       Artifacts(savePath, session.ID),
+	}
+
+	for _, event := range session.Events {
+		if event.Type == "annotation" {
+			info.Annotated = true
+			break
+		}
+	}
+
+	for _, path := range info.Files {
+		if stat, err := os.Stat(path); err == nil {
+			info.Size += stat.Size()
+		}
+	}
+
+	return info
+}
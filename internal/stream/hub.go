@@ -0,0 +1,227 @@
+// Package stream publishes live session events and analytics snapshots to local subscribers.
+// Messages are numbered so clients can resume after a reconnect, and slow consumers are
+// dropped instead of blocking the recorder.
+package stream
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+// Message kinds.
+const (
+	KindEvent     = "event"
+	KindAnalytics = "analytics"
+)
+
+// Default buffer sizes.
+const (
+	DefaultHistory = 1024 // Messages kept for resuming clients
+	DefaultBuffer  = 256  // Messages queued per subscriber before it is dropped
+)
+
+// Message is one item of the live stream.
+type Message struct {
+	Seq       uint64                   `json:"seq"`
+	Kind      string                   `json:"kind"`
+	SessionID string                   `json:"session_id"`
+	Time      time.Time                `json:"time"`
+	Event     *models.Event            `json:"event,omitempty"`
+	Analytics *models.SessionAnalytics `json:"analytics,omitempty"`
+}
+
+// Filter selects the messages a subscriber receives. Empty fields match everything.
+type Filter struct {
+	Session string
+	Types   []string // Event types; "analytics" selects analytics snapshots
+	Files   []string // Full paths, base names or glob patterns such as *.go
+}
+
+// Match reports whether a message passes the filter.
+// Analytics snapshots are not tied to a file, so the file filter does not apply to them.
+func (f Filter) Match(msg Message) bool {
+	if f.Session != "" && msg.SessionID != f.Session {
+		return false
+	}
+
+	kind := KindAnalytics
+	if msg.Event != nil {
+		kind = msg.Event.Type
+	}
+	if len(f.Types) > 0 && !contains(f.Types, kind) {
+		return false
+	}
+
+	if len(f.Files) > 0 && msg.Event != nil {
+		return matchFile(f.Files, msg.Event.Data.Filename)
+	}
+	return true
+}
+
+// Hub fans published messages out to subscribers and keeps a short history for resuming.
+type Hub struct {
+	mu      sync.Mutex
+	seq     uint64
+	history []Message // Ring buffer of the most recent messages
+	next    int       // Index in history the next message is written to
+	size    int       // Number of valid messages in history
+	buffer  int
+	subs    map[*Subscription]struct{}
+}
+
+// NewHub creates a hub keeping history messages for resuming and queueing up to
+// buffer messages per subscriber.
+func NewHub(history, buffer int) *Hub {
+	if history <= 0 {
+		history = DefaultHistory
+	}
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	return &Hub{
+		history: make([]Message, history),
+		buffer:  buffer,
+		subs:    make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscription receives messages from a hub on C until it is closed.
+type Subscription struct {
+	C       <-chan Message
+	ch      chan Message
+	filter  Filter
+	hub     *Hub
+	dropped bool
+}
+
+// Dropped reports whether the subscription was closed because it fell too far behind.
+// It is only meaningful after C has been closed.
+func (s *Subscription) Dropped() bool {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.dropped
+}
+
+// Close unsubscribes from the hub. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	if _, ok := s.hub.subs[s]; ok {
+		delete(s.hub.subs, s)
+		close(s.ch)
+	}
+}
+
+// PublishEvent publishes an event accepted by the recorder.
+func (h *Hub) PublishEvent(sessionID string, event models.Event) {
+	h.publish(Message{Kind: KindEvent, SessionID: sessionID, Time: event.Timestamp, Event: &event})
+}
+
+// PublishAnalytics publishes a regenerated analytics snapshot.
+func (h *Hub) PublishAnalytics(sessionID string, analytics *models.SessionAnalytics) {
+	h.publish(Message{Kind: KindAnalytics, SessionID: sessionID, Time: time.Now(), Analytics: analytics})
+}
+
+// publish numbers a message, records it in the history and delivers it without blocking.
+func (h *Hub) publish(msg Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	msg.Seq = h.seq
+
+	h.history[h.next] = msg
+	h.next = (h.next + 1) % len(h.history)
+	if h.size < len(h.history) {
+		h.size++
+	}
+
+	for sub := range h.subs {
+		if !sub.filter.Match(msg) {
+			continue
+		}
+		select {
+		case sub.ch <- msg:
+		default:
+			// Slow consumer: drop it rather than block the recorder
+			sub.dropped = true
+			delete(h.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// Subscribe registers a subscriber and returns the messages after sequence number since
+// that are still in the history. complete is false when older messages were already discarded,
+// so the client missed part of the stream. Pass since = 0 to receive only new messages.
+func (h *Hub) Subscribe(filter Filter, since uint64) (sub *Subscription, backlog []Message, complete bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	complete = true
+	if since > h.seq {
+		// The client saw a previous daemon's stream; replay everything we have
+		since, complete = 0, false
+		for i := 0; i < h.size; i++ {
+			msg := h.history[(h.next-h.size+len(h.history)+i)%len(h.history)]
+			if filter.Match(msg) {
+				backlog = append(backlog, msg)
+			}
+		}
+	} else if since > 0 {
+		start := (h.next - h.size + len(h.history)) % len(h.history)
+		if h.size > 0 && h.history[start].Seq > since+1 {
+			complete = false
+		}
+		for i := 0; i < h.size; i++ {
+			msg := h.history[(start+i)%len(h.history)]
+			if msg.Seq > since && filter.Match(msg) {
+				backlog = append(backlog, msg)
+			}
+		}
+	}
+
+	ch := make(chan Message, h.buffer)
+	sub = &Subscription{C: ch, ch: ch, filter: filter, hub: h}
+	h.subs[sub] = struct{}{}
+	return sub, backlog, complete
+}
+
+// Seq returns the sequence number of the last published message.
+func (h *Hub) Seq() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.seq
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// matchFile reports whether filename equals, ends with or matches one of the patterns.
+func matchFile(patterns []string, filename string) bool {
+	if filename == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		if pattern == filename || strings.HasSuffix(filename, string(filepath.Separator)+pattern) {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, filepath.Base(filename)); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, filename); ok {
+			return true
+		}
+	}
+	return false
+}
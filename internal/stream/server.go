@@ -0,0 +1,227 @@
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// keepAlive is how often idle connections receive a heartbeat.
+const keepAlive = 30 * time.Second
+
+// Handler serves the hub over HTTP:
+//
+//	GET /events  Server-Sent Events
+//	GET /ws      WebSocket (text frames, one JSON message each)
+//
+// Both accept the query parameters session, types and files (comma-separated) to filter
+// messages, and since=<seq> to resume after the given sequence number. SSE clients may
+// send Last-Event-ID instead of since.
+//
+// Requests must address the server by a loopback name, and browser requests must come
+// from a loopback origin, so web pages cannot read the stream of edits and commands.
+func Handler(hub *Hub) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /events", func(w http.ResponseWriter, r *http.Request) {
+		serveSSE(hub, w, r)
+	})
+	mux.HandleFunc("GET /ws", func(w http.ResponseWriter, r *http.Request) {
+		serveWebSocket(hub, w, r)
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsLoopbackHost(r.Host) {
+			http.Error(w, "host not allowed", http.StatusForbidden)
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" && !allowedOrigin(origin) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// allowedOrigin reports whether a browser Origin header names a page served from
+// this machine. Clients outside a browser, such as Neovim or curl, send no Origin.
+func allowedOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	return IsLoopbackHost(u.Host)
+}
+
+// IsLoopbackHost reports whether a host, with or without a port, is localhost or a
+// loopback address.
+func IsLoopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// notice is a control message sent to clients outside the numbered stream.
+type notice struct {
+	Kind string `json:"kind"` // "gap" when messages were missed, "dropped" when the client fell behind
+	Seq  uint64 `json:"seq"`  // Last sequence number published by the hub
+}
+
+// parseRequest reads the filter and resume point of a streaming request.
+func parseRequest(r *http.Request) (Filter, uint64, error) {
+	params := r.URL.Query()
+	filter := Filter{
+		Session: params.Get("session"),
+		Types:   SplitList(params.Get("types")),
+		Files:   SplitList(params.Get("files")),
+	}
+
+	since := params.Get("since")
+	if since == "" {
+		since = r.Header.Get("Last-Event-ID")
+	}
+	if since == "" {
+		return filter, 0, nil
+	}
+	seq, err := strconv.ParseUint(since, 10, 64)
+	if err != nil {
+		return filter, 0, fmt.Errorf("invalid since %q", since)
+	}
+	return filter, seq, nil
+}
+
+// serveSSE streams messages as Server-Sent Events until the client disconnects or is dropped.
+func serveSSE(hub *Hub, w http.ResponseWriter, r *http.Request) {
+	filter, since, err := parseRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	sub, backlog, complete := hub.Subscribe(filter, since)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	if !complete {
+		writeSSENotice(w, notice{Kind: "gap", Seq: hub.Seq()})
+	}
+	for _, msg := range backlog {
+		writeSSEMessage(w, msg)
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg, ok := <-sub.C:
+			if !ok {
+				if sub.Dropped() {
+					writeSSENotice(w, notice{Kind: "dropped", Seq: hub.Seq()})
+					flusher.Flush()
+				}
+				return
+			}
+			writeSSEMessage(w, msg)
+			flusher.Flush()
+		case <-ticker.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeSSEMessage(w http.ResponseWriter, msg Message) {
+	data, _ := json.Marshal(msg)
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", msg.Seq, msg.Kind, data)
+}
+
+func writeSSENotice(w http.ResponseWriter, n notice) {
+	data, _ := json.Marshal(n)
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", n.Kind, data)
+}
+
+// serveWebSocket streams messages over a WebSocket until the client disconnects or is dropped.
+func serveWebSocket(hub *Hub, w http.ResponseWriter, r *http.Request) {
+	filter, since, err := parseRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		return
+	}
+	defer conn.close()
+
+	sub, backlog, complete := hub.Subscribe(filter, since)
+	defer sub.Close()
+
+	if !complete {
+		if conn.writeJSON(notice{Kind: "gap", Seq: hub.Seq()}) != nil {
+			return
+		}
+	}
+	for _, msg := range backlog {
+		if conn.writeJSON(msg) != nil {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg, ok := <-sub.C:
+			if !ok {
+				if sub.Dropped() {
+					_ = conn.writeJSON(notice{Kind: "dropped", Seq: hub.Seq()})
+				}
+				return
+			}
+			if conn.writeJSON(msg) != nil {
+				return
+			}
+		case <-ticker.C:
+			if conn.writeFrame(opPing, nil) != nil {
+				return
+			}
+		case <-conn.done:
+			return
+		}
+	}
+}
+
+// SplitList splits a comma-separated parameter, dropping blank items.
+func SplitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
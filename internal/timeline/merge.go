@@ -0,0 +1,103 @@
+// Package timeline combines and divides recorded sessions by rewriting their event streams.
+package timeline
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+// GapEventType marks the break between two merged source sessions.
+// The aggregator treats the interval following a gap marker as a break, not idle time.
+const GapEventType = "session_gap"
+
+// Merge interleaves the events of several sessions into a single session.
+// Only the earliest session_start and the latest session_end are kept, every event
+// records its source session ID, and a session_gap marker is inserted wherever one
+// source ends before the next begins.
+func Merge(newID string, sources []*models.Session) (*models.Session, error) {
+	if len(sources) < 2 {
+		return nil, fmt.Errorf("merge requires at least 2 sessions")
+	}
+
+	ordered := make([]*models.Session, len(sources))
+	copy(ordered, sources)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].StartTime.Before(ordered[j].StartTime)
+	})
+
+	first, last := ordered[0], ordered[0]
+	for _, src := range ordered {
+		if endOf(src).After(endOf(last)) {
+			last = src
+		}
+	}
+
+	var events []models.Event
+	for _, src := range ordered {
+		for _, event := range src.Events {
+			if event.Type == "session_start" && src != first {
+				continue
+			}
+			if event.Type == "session_end" && src != last {
+				continue
+			}
+			if event.Source == "" {
+				event.Source = src.ID
+			}
+			events = append(events, event)
+		}
+	}
+
+	// Insert gap markers between sources that do not overlap in time
+	coveredUntil := endOf(first)
+	for i := 1; i < len(ordered); i++ {
+		src := ordered[i]
+		if src.StartTime.After(coveredUntil) {
+			events = append(events, models.Event{
+				Type:      GapEventType,
+				Timestamp: coveredUntil,
+				Data: models.EventData{
+					Note: fmt.Sprintf("Gap of %s before %s", src.StartTime.Sub(coveredUntil).Round(time.Second), src.ID),
+				},
+				Source: src.ID,
+			})
+		}
+		if end := endOf(src); end.After(coveredUntil) {
+			coveredUntil = end
+		}
+	}
+
+	// Markers were appended last, so a stable sort keeps them after events sharing their timestamp
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+
+	return &models.Session{
+		ID:            newID,
+		ProjectPath:   first.ProjectPath,
+		SavePath:      first.SavePath,
+		OutputFormat:  first.OutputFormat,
+		ExportOptions: maps.Clone(first.ExportOptions),
+		StartTime:     first.StartTime,
+		EndTime:       endOf(last),
+		Events:        events,
+		Active:        false,
+	}, nil
+}
+
+// endOf returns when a session ended, falling back to its last event for sessions
+// that were never ended cleanly.
+func endOf(session *models.Session) time.Time {
+	end := session.EndTime
+	if n := len(session.Events); n > 0 && session.Events[n-1].Timestamp.After(end) {
+		end = session.Events[n-1].Timestamp
+	}
+	if end.IsZero() {
+		end = session.StartTime
+	}
+	return end
+}
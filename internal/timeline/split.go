@@ -0,0 +1,127 @@
+package timeline
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/aggregator"
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+// Split divides a session at the given time into two ended sessions.
+// Events before at go to the first session and the rest to the second; each part
+// gets its own session_start/session_end so start and end times stay consistent.
+func Split(session *models.Session, at time.Time, firstID, secondID string) (*models.Session, *models.Session, error) {
+	var before, after []models.Event
+	for _, event := range session.Events {
+		if event.Type == "session_start" || event.Type == "session_end" {
+			continue
+		}
+		if event.Timestamp.Before(at) {
+			before = append(before, event)
+		} else {
+			after = append(after, event)
+		}
+	}
+
+	if len(before) == 0 || len(after) == 0 {
+		return nil, nil, fmt.Errorf("split point %s leaves one part without events", at.Format(time.RFC3339))
+	}
+
+	firstEnd := before[len(before)-1].Timestamp
+	secondStart := after[0].Timestamp
+	secondEnd := endOf(session)
+
+	first := &models.Session{
+		ID:            firstID,
+		ProjectPath:   session.ProjectPath,
+		SavePath:      session.SavePath,
+		OutputFormat:  session.OutputFormat,
+		ExportOptions: maps.Clone(session.ExportOptions),
+		StartTime:     session.StartTime,
+		EndTime:       firstEnd,
+		Events:        bracket(before, session.StartTime, firstEnd, fmt.Sprintf("Split from %s", session.ID)),
+	}
+	second := &models.Session{
+		ID:            secondID,
+		ProjectPath:   session.ProjectPath,
+		SavePath:      session.SavePath,
+		OutputFormat:  session.OutputFormat,
+		ExportOptions: maps.Clone(session.ExportOptions),
+		StartTime:     secondStart,
+		EndTime:       secondEnd,
+		Events:        bracket(after, secondStart, secondEnd, fmt.Sprintf("Split from %s", session.ID)),
+	}
+
+	return first, second, nil
+}
+
+// ParseSplitPoint resolves --at: a 1-based annotation index, an RFC 3339 timestamp,
+// or a wall-clock time (15:04 or 15:04:05) on the session's start date.
+func ParseSplitPoint(session *models.Session, value string) (time.Time, error) {
+	if index, err := strconv.Atoi(value); err == nil {
+		return annotationTime(session, index)
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	start := session.StartTime.Local()
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.ParseInLocation(layout, value, start.Location()); err == nil {
+			return time.Date(start.Year(), start.Month(), start.Day(),
+				t.Hour(), t.Minute(), t.Second(), 0, start.Location()), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid split point %q: use an annotation index, RFC 3339 time or HH:MM[:SS]", value)
+}
+
+// SuggestSplits returns idle gaps of at least minGap, longest first.
+// Splitting at a gap's EndTime starts the second session with the work that resumed.
+func SuggestSplits(session *models.Session, minGap time.Duration) []models.IdleGap {
+	config := aggregator.DefaultConfig()
+	config.IdleThreshold = minGap
+
+	gaps := aggregator.New(config).FindIdleGaps(session.Events)
+	sort.SliceStable(gaps, func(i, j int) bool {
+		return gaps[i].Duration > gaps[j].Duration
+	})
+	return gaps
+}
+
+// annotationTime returns the timestamp of the index-th (1-based) annotation.
+func annotationTime(session *models.Session, index int) (time.Time, error) {
+	n := 0
+	for _, event := range session.Events {
+		if event.Type != "annotation" {
+			continue
+		}
+		n++
+		if n == index {
+			return event.Timestamp, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("session has %d annotations, no annotation #%d", n, index)
+}
+
+// bracket wraps events with session_start and session_end events.
+func bracket(events []models.Event, start, end time.Time, note string) []models.Event {
+	result := make([]models.Event, 0, len(events)+2)
+	result = append(result, models.Event{
+		Type:      "session_start",
+		Timestamp: start,
+		Data:      models.EventData{Note: note},
+	})
+	result = append(result, events...)
+	result = append(result, models.Event{
+		Type:      "session_end",
+		Timestamp: end,
+		Data:      models.EventData{Note: "Debugging session ended"},
+	})
+	return result
+}
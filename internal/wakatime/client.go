@@ -0,0 +1,58 @@
+package wakatime
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// APIKeyEnv is the environment variable holding the API key used by Send. The key is never
+// stored in sessions or export options.
+const APIKeyEnv = "WAKATIME_API_KEY"
+
+// bulkLimit is the most heartbeats the bulk endpoint accepts per request.
+const bulkLimit = 25
+
+// Send posts heartbeats to a WakaTime-compatible server such as a self-hosted Wakapi.
+// apiURL is the API base, e.g. http://localhost:3000/api; heartbeats are sent in batches
+// to {apiURL}/users/current/heartbeats.bulk.
+func Send(apiURL, apiKey string, heartbeats []Heartbeat) error {
+	if apiKey == "" {
+		return fmt.Errorf("no API key: set %s", APIKeyEnv)
+	}
+	endpoint := strings.TrimRight(apiURL, "/") + "/users/current/heartbeats.bulk"
+	client := &http.Client{Timeout: 30 * time.Second}
+	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(apiKey))
+
+	for start := 0; start < len(heartbeats); start += bulkLimit {
+		end := min(start+bulkLimit, len(heartbeats))
+		body, err := json.Marshal(heartbeats[start:end])
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", auth)
+		req.Header.Set("User-Agent", "capytrace")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		_ = resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("%s returned %s: %s", endpoint, resp.Status, strings.TrimSpace(string(msg)))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+// Package wakatime converts between capytrace sessions and WakaTime heartbeats, so
+// histories recorded by either tool can be analysed together.
+package wakatime
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+// HeartbeatInterval is how often a heartbeat is repeated for the same file while it stays
+// in use, matching the WakaTime editor plugins.
+const HeartbeatInterval = 2 * time.Minute
+
+// Heartbeat is a WakaTime heartbeat as sent to /users/current/heartbeats.bulk and found in
+// data dumps.
+type Heartbeat struct {
+	Entity   string  `json:"entity"`
+	Type     string  `json:"type"` // "file"
+	Category string  `json:"category,omitempty"`
+	Time     float64 `json:"time"` // Unix seconds
+	Project  string  `json:"project,omitempty"`
+	Language string  `json:"language,omitempty"`
+	IsWrite  bool    `json:"is_write"`
+	Lineno   int     `json:"lineno,omitempty"`
+	Lines    int     `json:"lines,omitempty"`
+}
+
+// Timestamp returns the heartbeat time.
+func (h Heartbeat) Timestamp() time.Time {
+	sec := int64(h.Time)
+	return time.Unix(sec, int64((h.Time-float64(sec))*1e9))
+}
+
+// FromSession converts the file_edit, file_open and cursor_move events of a session into
+// heartbeats. Like the editor plugins, a file gets a new heartbeat when it becomes the
+// current file, when it is first written after reads, or every HeartbeatInterval.
+func FromSession(session *models.Session) []Heartbeat {
+	project := filepath.Base(session.ProjectPath)
+	fileTypes := make(map[string]string)
+
+	type last struct {
+		at      time.Time
+		isWrite bool
+	}
+	lastByFile := make(map[string]last)
+	current := ""
+
+	var heartbeats []Heartbeat
+	for _, ev := range session.Events {
+		switch ev.Type {
+		case "file_edit", "file_open", "cursor_move":
+		default:
+			continue
+		}
+		file := ev.Data.Filename
+		if file == "" || !filepath.IsAbs(file) {
+			continue // Scratch buffers and plugin windows such as NvimTree
+		}
+		if ev.Data.FileType != "" {
+			fileTypes[file] = ev.Data.FileType
+		}
+
+		isWrite := ev.Type == "file_edit"
+		prev, seen := lastByFile[file]
+		if seen && file == current && ev.Timestamp.Sub(prev.at) < HeartbeatInterval && (prev.isWrite || !isWrite) {
+			continue
+		}
+		current = file
+		lastByFile[file] = last{at: ev.Timestamp, isWrite: isWrite}
+
+		heartbeats = append(heartbeats, Heartbeat{
+			Entity:   file,
+			Type:     "file",
+			Category: "coding",
+			Time:     float64(ev.Timestamp.UnixNano()) / 1e9,
+			Project:  project,
+			Language: Language(fileTypes[file], file),
+			IsWrite:  isWrite,
+			Lineno:   ev.Data.Line,
+			Lines:    ev.Data.LineCount,
+		})
+	}
+	return heartbeats
+}
+
+// languages maps Neovim filetypes to WakaTime language names.
+var languages = map[string]string{
+	"bash":            "Bash",
+	"c":               "C",
+	"cpp":             "C++",
+	"cs":              "C#",
+	"css":             "CSS",
+	"dart":            "Dart",
+	"dockerfile":      "Docker",
+	"elixir":          "Elixir",
+	"go":              "Go",
+	"gomod":           "Go",
+	"haskell":         "Haskell",
+	"html":            "HTML",
+	"java":            "Java",
+	"javascript":      "JavaScript",
+	"javascriptreact": "JavaScript",
+	"json":            "JSON",
+	"kotlin":          "Kotlin",
+	"lua":             "Lua",
+	"make":            "Makefile",
+	"markdown":        "Markdown",
+	"nix":             "Nix",
+	"php":             "PHP",
+	"python":          "Python",
+	"ruby":            "Ruby",
+	"rust":            "Rust",
+	"scss":            "SCSS",
+	"sh":              "Bash",
+	"sql":             "SQL",
+	"swift":           "Swift",
+	"toml":            "TOML",
+	"typescript":      "TypeScript",
+	"typescriptreact": "TSX",
+	"vim":             "VimL",
+	"yaml":            "YAML",
+	"zig":             "Zig",
+	"zsh":             "Bash",
+}
+
+// extensions maps file extensions to filetypes for files without a recorded filetype.
+var extensions = map[string]string{
+	".c": "c", ".cc": "cpp", ".cpp": "cpp", ".h": "c", ".cs": "cs", ".css": "css",
+	".go": "go", ".hs": "haskell", ".html": "html", ".java": "java", ".js": "javascript",
+	".jsx": "javascriptreact", ".json": "json", ".kt": "kotlin", ".lua": "lua", ".md": "markdown",
+	".nix": "nix", ".php": "php", ".py": "python", ".rb": "ruby", ".rs": "rust", ".scss": "scss",
+	".sh": "sh", ".sql": "sql", ".swift": "swift", ".toml": "toml", ".ts": "typescript",
+	".tsx": "typescriptreact", ".vim": "vim", ".yaml": "yaml", ".yml": "yaml", ".zig": "zig",
+}
+
+// Language returns the WakaTime language for a Neovim filetype, guessing from the file
+// extension when the filetype is unknown.
+func Language(fileType, file string) string {
+	if fileType == "" {
+		fileType = extensions[strings.ToLower(filepath.Ext(file))]
+	}
+	if name, ok := languages[fileType]; ok {
+		return name
+	}
+	if fileType == "" {
+		return ""
+	}
+	return strings.ToUpper(fileType[:1]) + fileType[1:]
+}
+
+// FileType returns the Neovim filetype for a WakaTime language, or the lowercased name.
+// Languages with several filetypes map to the alphabetically first, e.g. Bash to "bash".
+func FileType(language string) string {
+	best := ""
+	for fileType, name := range languages {
+		if name == language && (best == "" || fileType < best) {
+			best = fileType
+		}
+	}
+	if best != "" {
+		return best
+	}
+	return strings.ToLower(language)
+}
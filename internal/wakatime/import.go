@@ -0,0 +1,159 @@
+package wakatime
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andev0x/capytrace.nvim/internal/models"
+)
+
+// DefaultIdleTimeout is the gap between heartbeats that ends an imported session,
+// WakaTime's own default keystroke timeout.
+const DefaultIdleTimeout = 15 * time.Minute
+
+// dump covers the JSON shapes heartbeats are found in: a bare array, an API response
+// ({"data": [...]}) and the account data export ({"days": [{"heartbeats": [...]}]}).
+type dump struct {
+	Data []Heartbeat `json:"data"`
+	Days []struct {
+		Heartbeats []Heartbeat `json:"heartbeats"`
+	} `json:"days"`
+}
+
+// Parse reads heartbeats from any of the supported dump formats, sorted by time.
+func Parse(data []byte) ([]Heartbeat, error) {
+	var heartbeats []Heartbeat
+	if err := json.Unmarshal(data, &heartbeats); err != nil {
+		var d dump
+		if err := json.Unmarshal(data, &d); err != nil {
+			return nil, fmt.Errorf("not a heartbeat array, API response or data export: %w", err)
+		}
+		heartbeats = d.Data
+		for _, day := range d.Days {
+			heartbeats = append(heartbeats, day.Heartbeats...)
+		}
+	}
+
+	sort.SliceStable(heartbeats, func(i, j int) bool { return heartbeats[i].Time < heartbeats[j].Time })
+	return heartbeats, nil
+}
+
+// unsafeID matches characters not allowed in generated session IDs.
+var unsafeID = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// ToSessions groups file heartbeats into capytrace sessions, starting a new session when
+// the project changes or no heartbeat arrives for idleTimeout. Session IDs are derived
+// from the start time and project, so importing the same dump twice yields the same IDs.
+func ToSessions(heartbeats []Heartbeat, idleTimeout time.Duration) []*models.Session {
+	var sessions []*models.Session
+	var current *models.Session
+	var currentProject string
+	var files []string
+	var last time.Time
+	ticks := make(map[string]int)
+
+	finish := func() {
+		if current == nil {
+			return
+		}
+		current.ProjectPath = projectRoot(currentProject, files)
+		current.EndTime = last
+		current.Events = append(current.Events, models.Event{
+			Type:      "session_end",
+			Timestamp: last,
+			Data:      models.EventData{Note: "End of imported WakaTime activity"},
+		})
+		sessions = append(sessions, current)
+		current = nil
+	}
+
+	for _, hb := range heartbeats {
+		if hb.Type != "" && hb.Type != "file" {
+			continue // Domains and apps have no file to attach events to
+		}
+		at := hb.Timestamp()
+		project := hb.Project
+		if project == "" {
+			project = "unknown"
+		}
+
+		if current != nil && (currentProject != project || at.Sub(last) > idleTimeout) {
+			finish()
+		}
+		if current == nil {
+			id := fmt.Sprintf("waka_%s_%s", at.UTC().Format("20060102-150405"), unsafeID.ReplaceAllString(project, "_"))
+			current = &models.Session{
+				ID:           id,
+				OutputFormat: "markdown",
+				StartTime:    at,
+				Events: []models.Event{{
+					Type:      "session_start",
+					Timestamp: at,
+					Data:      models.EventData{Note: fmt.Sprintf("Imported from WakaTime (project %s)", project)},
+				}},
+			}
+			currentProject = project
+			files = nil
+			ticks = make(map[string]int)
+		}
+		last = at
+		if filepath.IsAbs(hb.Entity) {
+			files = append(files, hb.Entity)
+		}
+
+		if hb.IsWrite {
+			// Every write heartbeat advances the file's change counter, so velocity and
+			// activity blocks have something to measure.
+			ticks[hb.Entity]++
+			current.Events = append(current.Events, models.Event{
+				Type:      "file_edit",
+				Timestamp: at,
+				Data: models.EventData{
+					Filename:    hb.Entity,
+					Line:        hb.Lineno,
+					LineCount:   hb.Lines,
+					ChangedTick: ticks[hb.Entity],
+				},
+			})
+			continue
+		}
+		current.Events = append(current.Events, models.Event{
+			Type:      "file_open",
+			Timestamp: at,
+			Data: models.EventData{
+				Filename: hb.Entity,
+				FileType: FileType(hb.Language),
+			},
+		})
+	}
+	finish()
+
+	return sessions
+}
+
+// projectRoot guesses the project directory of an imported session from the files it
+// touched: the nearest ancestor of a file named after the project, or else the deepest
+// directory containing every file. Without absolute paths it falls back to the name.
+func projectRoot(project string, files []string) string {
+	if len(files) == 0 {
+		return project
+	}
+	for dir := filepath.Dir(files[0]); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if filepath.Base(dir) == project {
+			return dir
+		}
+	}
+
+	root := filepath.Dir(files[0])
+	for _, file := range files[1:] {
+		for root != filepath.Dir(root) && !strings.HasPrefix(file, root+string(filepath.Separator)) {
+			root = filepath.Dir(root)
+		}
+	}
+	return root
+}
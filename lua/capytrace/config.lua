@@ -2,7 +2,7 @@ local M = {}
 
 -- Default configuration
 local default_config = {
-	output_format = "markdown", -- or "json" or "sqlite"
+	output_format = "markdown", -- "json", "sqlite", "html", "csv", "tsv", "ics", "org", "wakatime", "postmortem", "notes", "cast", or several joined with "+" (e.g. "markdown+sqlite")
 	save_path = vim.fn.expand("~/capytrace_logs/"),
 	binary_path = nil,
 	auto_download_binary = true,
@@ -11,6 +11,13 @@ local default_config = {
 	record_git_diff = true,
 	auto_save_on_exit = true,
 	open_report_on_end = true,
+	template_dir = nil, -- Directory with session.md / summary.md / session.html overriding the built-in report templates
+	summary_mermaid = false, -- Add Mermaid Gantt, pie and transition diagrams to SESSION_SUMMARY.md ("markdown" format)
+	notes_dir = nil, -- Vault directory for the "notes" format (defaults to save_path/notes)
+	notes_path = nil, -- Daily-note path pattern inside notes_dir, e.g. "Journal/{year}/{date}.md" (default "{date}.md")
+	wakatime_url = nil, -- e.g. "http://localhost:3000/api" to send "wakatime" heartbeats to a compatible server ($WAKATIME_API_KEY)
+	anonymize = false, -- true (or { drop_text = true, drop_args = true }) writes pseudonymized exports to save_path/anonymized
+	stream_listen = nil, -- e.g. "127.0.0.1:7879" to stream live events over SSE (/events) and WebSocket (/ws)
 	max_cursor_events = 100, -- Limit cursor movement recordings
 
 	-- Smart Filter configuration (Anti-Spam Cursor Filter)
@@ -7,6 +7,8 @@ local session_id = nil
 local go_process = nil
 local daemon_chan_id = nil
 local request_seq = 0
+local pending_requests = {}
+local stdout_partial = ""
 
 local function get_go_binary_path()
 	local cfg = config.get()
@@ -176,7 +178,7 @@ local function ensure_go_binary()
 	return nil
 end
 
-local function send_daemon_request(command, args)
+local function send_daemon_request(command, args, on_response)
 	if not go_process or not daemon_chan_id then
 		return nil
 	end
@@ -188,11 +190,31 @@ local function send_daemon_request(command, args)
 		args = args or {},
 	}
 
+	if on_response then
+		pending_requests[request_seq] = on_response
+	end
+
 	local line = vim.json.encode(req)
 	vim.api.nvim_chan_send(daemon_chan_id, line .. "\n")
 	return true
 end
 
+-- Dispatch a daemon response line to the callback registered for its request id
+local function handle_daemon_response(line)
+	local ok, resp = pcall(vim.json.decode, line)
+	if not ok or type(resp) ~= "table" or not resp.id then
+		return
+	end
+
+	local callback = pending_requests[resp.id]
+	if callback then
+		pending_requests[resp.id] = nil
+		vim.schedule(function()
+			callback(resp)
+		end)
+	end
+end
+
 local function start_daemon()
 	if go_process then
 		return true
@@ -206,13 +228,22 @@ local function start_daemon()
 	local stdout_chunks = {}
 	local stderr_chunks = {}
 
-	local chan = vim.fn.jobstart({ go_binary, "daemon" }, {
+	local cmd = { go_binary, "daemon" }
+	if config.get().stream_listen then
+		vim.list_extend(cmd, { "--listen", config.get().stream_listen })
+	end
+
+	local chan = vim.fn.jobstart(cmd, {
 		stdout_buffered = false,
 		stderr_buffered = false,
 		on_stdout = function(_, data)
+			-- Job output arrives in chunks; the last element is an unfinished line
+			data[1] = stdout_partial .. data[1]
+			stdout_partial = table.remove(data)
 			for _, line in ipairs(data) do
 				if line ~= "" then
 					table.insert(stdout_chunks, line)
+					handle_daemon_response(line)
 				end
 			end
 		end,
@@ -244,6 +275,8 @@ local function stop_daemon()
 	end
 	daemon_chan_id = nil
 	go_process = nil
+	pending_requests = {}
+	stdout_partial = ""
 end
 
 -- Helper function to execute Go binary
@@ -274,12 +307,41 @@ function M.start_session(project_name)
 	project_name = project_name or vim.fn.fnamemodify(vim.fn.getcwd(), ":t")
 	session_id = os.time() .. "_" .. project_name
 
-	local result = exec_go_command("start", {
+	local args = {
 		session_id,
 		vim.fn.getcwd(),
 		config.get().save_path,
 		config.get().output_format,
-	})
+	}
+	if config.get().template_dir then
+		vim.list_extend(args, { "--template", vim.fn.expand(config.get().template_dir) })
+	end
+	if config.get().summary_mermaid then
+		vim.list_extend(args, { "--option", "summary.mermaid=true" })
+	end
+	if config.get().notes_dir then
+		vim.list_extend(args, { "--option", "notes.dir=" .. vim.fn.expand(config.get().notes_dir) })
+	end
+	if config.get().notes_path then
+		vim.list_extend(args, { "--option", "notes.path=" .. config.get().notes_path })
+	end
+	if config.get().wakatime_url then
+		vim.list_extend(args, { "--option", "wakatime.url=" .. config.get().wakatime_url })
+	end
+	local anonymize = config.get().anonymize
+	if anonymize then
+		table.insert(args, "--anonymize")
+		if type(anonymize) == "table" then
+			if anonymize.drop_text then
+				vim.list_extend(args, { "--option", "anonymize.drop_text=true" })
+			end
+			if anonymize.drop_args then
+				vim.list_extend(args, { "--option", "anonymize.drop_args=true" })
+			end
+		end
+	end
+
+	local result = exec_go_command("start", args)
 
 	if vim.v.shell_error == 0 then
 		start_daemon()
@@ -383,18 +445,23 @@ function M.record_edit(bufnr, changedtick)
 	})
 end
 
--- Record terminal command
-function M.record_terminal_command(cmd)
+-- Record terminal command (exit_code is optional)
+function M.record_terminal_command(cmd, exit_code)
 	if not session_active then
 		return
 	end
 
+	local args = { session_id, config.get().save_path, cmd }
+	if exit_code then
+		table.insert(args, tostring(exit_code))
+	end
+
 	if daemon_chan_id then
-		send_daemon_request("record-terminal", { session_id, config.get().save_path, cmd })
+		send_daemon_request("record-terminal", args)
 		return
 	end
 
-	exec_go_command("record-terminal", { session_id, config.get().save_path, cmd })
+	exec_go_command("record-terminal", args)
 end
 
 -- Record file open
@@ -516,6 +583,15 @@ function M.setup_autocommands()
 				M.record_terminal_command("Terminal opened")
 			end,
 		})
+
+		-- Terminal buffers are named term://{cwd}//{pid}:{cmd}
+		vim.api.nvim_create_autocmd("TermClose", {
+			group = group,
+			callback = function(ev)
+				local cmd = vim.api.nvim_buf_get_name(ev.buf):match("^term://.-//%d+:(.*)$") or "terminal"
+				M.record_terminal_command(cmd, vim.v.event.status)
+			end,
+		})
 	end
 
 	-- Log file open
@@ -587,6 +663,219 @@ function M.resume_session(session_name)
 	end
 end
 
+-- Rename, copy, delete, archive or unarchive a stored session
+function M.manage_session(action, id, new_id, on_done)
+	if session_active and id == session_id and action ~= "unarchive" then
+		vim.notify("Please end current session first", vim.log.levels.WARN)
+		return
+	end
+
+	local args = { id, config.get().save_path }
+	if new_id then
+		table.insert(args, new_id)
+	end
+
+	local function finish(ok, message)
+		vim.notify(message, ok and vim.log.levels.INFO or vim.log.levels.ERROR)
+		if ok and on_done then
+			on_done()
+		end
+	end
+
+	if daemon_chan_id then
+		send_daemon_request(action, args, function(resp)
+			finish(resp.ok, resp.ok and resp.result or ("Failed to " .. action .. " session: " .. resp.error))
+		end)
+		return
+	end
+
+	local result = exec_go_command(action, args)
+	if vim.v.shell_error == 0 then
+		finish(true, vim.trim(result))
+	else
+		finish(false, "Failed to " .. action .. " session: " .. result)
+	end
+end
+
+-- Split a finished session, offering long idle gaps as split points
+function M.split_session(id)
+	local save_path = config.get().save_path
+
+	local function do_split(at)
+		if daemon_chan_id then
+			send_daemon_request("split", { id, save_path, at }, function(resp)
+				if resp.ok then
+					vim.notify(resp.result, vim.log.levels.INFO)
+				else
+					vim.notify("Failed to split session: " .. resp.error, vim.log.levels.ERROR)
+				end
+			end)
+			return
+		end
+
+		local result = exec_go_command("split", { id, save_path, "--at", at })
+		if vim.v.shell_error == 0 then
+			vim.notify(vim.trim(result), vim.log.levels.INFO)
+		else
+			vim.notify("Failed to split session: " .. result, vim.log.levels.ERROR)
+		end
+	end
+
+	local function choose(suggestions)
+		local items = {}
+		for _, suggestion in ipairs(suggestions) do
+			table.insert(items, suggestion)
+		end
+		table.insert(items, { manual = true })
+
+		vim.ui.select(items, {
+			prompt = "Split " .. id .. " at:",
+			format_item = function(item)
+				if item.manual then
+					return "Enter time or annotation index..."
+				end
+				return item.at .. " (after " .. item.gap .. " idle)"
+			end,
+		}, function(item)
+			if not item then
+				return
+			end
+			if item.manual then
+				vim.ui.input({ prompt = "Split at (HH:MM, RFC 3339 or annotation #): " }, function(at)
+					if at and at ~= "" then
+						do_split(at)
+					end
+				end)
+				return
+			end
+			do_split(item.at)
+		end)
+	end
+
+	local function decode(raw)
+		local ok, suggestions = pcall(vim.json.decode, raw)
+		return ok and type(suggestions) == "table" and suggestions or {}
+	end
+
+	if daemon_chan_id then
+		send_daemon_request("split-suggest", { id, save_path }, function(resp)
+			choose(resp.ok and decode(resp.result) or {})
+		end)
+		return
+	end
+
+	local result = exec_go_command("split", { id, save_path, "--suggest", "--json" })
+	choose(vim.v.shell_error == 0 and decode(result) or {})
+end
+
+-- Play a recorded session back in a terminal tab
+function M.replay_session(id)
+	local go_binary = ensure_go_binary()
+	if not go_binary then
+		return
+	end
+
+	vim.cmd("tabnew")
+	vim.fn.termopen({ go_binary, "replay", id, config.get().save_path })
+	vim.cmd("startinsert")
+end
+
+-- Open a PR description, commit message or postmortem draft of a session in a new buffer
+function M.draft_session(id, kind)
+	kind = kind or "pr"
+
+	local function show(content)
+		vim.cmd("new")
+		local bufnr = vim.api.nvim_get_current_buf()
+		vim.api.nvim_buf_set_lines(bufnr, 0, -1, false, vim.split(content, "\n", { plain = true }))
+		vim.bo[bufnr].filetype = kind == "commit" and "gitcommit" or "markdown"
+		vim.bo[bufnr].buftype = "nofile"
+		vim.bo[bufnr].bufhidden = "wipe"
+	end
+
+	if daemon_chan_id then
+		send_daemon_request("draft", { id, config.get().save_path, kind }, function(resp)
+			if resp.ok then
+				show(resp.result)
+			else
+				vim.notify("Failed to draft: " .. resp.error, vim.log.levels.ERROR)
+			end
+		end)
+		return
+	end
+
+	local result = exec_go_command("draft", { id, config.get().save_path, "--kind", kind })
+	if vim.v.shell_error == 0 then
+		show(result)
+	else
+		vim.notify("Failed to draft: " .. result, vim.log.levels.ERROR)
+	end
+end
+
+-- Open a side-by-side comparison of two sessions in a new buffer
+function M.diff_sessions(id_a, id_b)
+	local result = exec_go_command("diff", { id_a, id_b, config.get().save_path })
+	if vim.v.shell_error ~= 0 then
+		vim.notify("Failed to diff sessions: " .. result, vim.log.levels.ERROR)
+		return
+	end
+
+	vim.cmd("new")
+	local bufnr = vim.api.nvim_get_current_buf()
+	vim.api.nvim_buf_set_lines(bufnr, 0, -1, false, vim.split(result, "\n", { plain = true }))
+	vim.bo[bufnr].filetype = "markdown"
+	vim.bo[bufnr].buftype = "nofile"
+	vim.bo[bufnr].bufhidden = "wipe"
+end
+
+-- Load the edited lines, diagnostics, notes or hotspots of a session into the quickfix
+-- list, opening it in Telescope when telescope is true
+function M.load_locations(id, kind, rank, telescope)
+	kind = kind or "edits"
+	rank = rank or "count"
+
+	local function load(encoded)
+		local ok, items = pcall(vim.json.decode, encoded)
+		if not ok or type(items) ~= "table" then
+			vim.notify("Failed to read locations", vim.log.levels.ERROR)
+			return
+		end
+		if #items == 0 then
+			vim.notify("No " .. kind .. " recorded in " .. id, vim.log.levels.INFO)
+			return
+		end
+		vim.fn.setqflist({}, " ", { title = "capytrace " .. kind .. ": " .. id, items = items })
+
+		if telescope then
+			local has_telescope, builtin = pcall(require, "telescope.builtin")
+			if has_telescope then
+				builtin.quickfix()
+				return
+			end
+			vim.notify("Telescope not found, opening the quickfix list", vim.log.levels.WARN)
+		end
+		vim.cmd("copen")
+	end
+
+	if daemon_chan_id then
+		send_daemon_request("locations", { id, config.get().save_path, kind, rank }, function(resp)
+			if resp.ok then
+				load(resp.result)
+			else
+				vim.notify("Failed to list locations: " .. resp.error, vim.log.levels.ERROR)
+			end
+		end)
+		return
+	end
+
+	local result = exec_go_command("locations", { id, config.get().save_path, "--kind", kind, "--rank", rank, "--json" })
+	if vim.v.shell_error == 0 then
+		load(result)
+	else
+		vim.notify("Failed to list locations: " .. result, vim.log.levels.ERROR)
+	end
+end
+
 -- Setup function
 function M.setup(opts)
 	config.setup(opts)
@@ -631,6 +920,84 @@ function M.setup(opts)
 		M.resume_session(args.args)
 	end, { nargs = 1, desc = "Resume a previous session" })
 
+	vim.api.nvim_create_user_command("CapyTraceManage", function(args)
+		local action, id, new_id = unpack(args.fargs)
+		if (action == "rename" or action == "copy") and not new_id then
+			vim.notify("Usage: CapyTraceManage " .. action .. " <session_id> <new_id>", vim.log.levels.WARN)
+			return
+		end
+		M.manage_session(action, id, new_id)
+	end, {
+		nargs = "+",
+		complete = function(_, line)
+			if #vim.split(line, "%s+") <= 2 then
+				return { "rename", "copy", "delete", "archive", "unarchive" }
+			end
+			return M.list_sessions()
+		end,
+		desc = "Rename, copy, delete, archive or unarchive a session",
+	})
+
+	vim.api.nvim_create_user_command("CapyTraceSplit", function(args)
+		M.split_session(args.args)
+	end, {
+		nargs = 1,
+		complete = function()
+			return M.list_sessions()
+		end,
+		desc = "Split a session at an idle gap, time or annotation",
+	})
+
+	vim.api.nvim_create_user_command("CapyTraceReplay", function(args)
+		M.replay_session(args.args)
+	end, {
+		nargs = 1,
+		complete = function()
+			return M.list_sessions()
+		end,
+		desc = "Play back a recorded session in a terminal",
+	})
+
+	vim.api.nvim_create_user_command("CapyTraceDraft", function(args)
+		M.draft_session(args.fargs[1], args.fargs[2])
+	end, {
+		nargs = "+",
+		complete = function(_, line)
+			if #vim.split(line, "%s+") <= 2 then
+				return M.list_sessions()
+			end
+			return { "pr", "commit", "postmortem" }
+		end,
+		desc = "Draft a PR description, commit message or postmortem from a session",
+	})
+
+	vim.api.nvim_create_user_command("CapyTraceDiff", function(args)
+		M.diff_sessions(args.fargs[1], args.fargs[2])
+	end, {
+		nargs = 2,
+		complete = function()
+			return M.list_sessions()
+		end,
+		desc = "Compare two sessions side by side",
+	})
+
+	vim.api.nvim_create_user_command("CapyTraceLocations", function(args)
+		M.load_locations(args.fargs[1], args.fargs[2], args.fargs[3], args.bang)
+	end, {
+		nargs = "+",
+		bang = true,
+		complete = function(_, line)
+			local count = #vim.split(line, "%s+")
+			if count <= 2 then
+				return M.list_sessions()
+			elseif count == 3 then
+				return { "edits", "diagnostics", "annotations", "hotspots" }
+			end
+			return { "count", "recent" }
+		end,
+		desc = "Load a session's edits, diagnostics, notes or hotspots into quickfix (! for Telescope)",
+	})
+
 	vim.api.nvim_create_user_command("CapyTraceSessions", function()
 		local ok, telescope = pcall(require, "telescope.builtin")
 		if not ok then
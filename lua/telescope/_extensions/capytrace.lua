@@ -33,9 +33,87 @@ extension.notes = function(opts)
 	}, opts))
 end
 
+-- Session picker with rename/copy/delete/archive actions backed by the daemon
+extension.manage = function(opts)
+	opts = opts or {}
+	local capytrace = require("capytrace")
+	local pickers = require("telescope.pickers")
+	local finders = require("telescope.finders")
+	local conf = require("telescope.config").values
+	local actions = require("telescope.actions")
+	local action_state = require("telescope.actions.state")
+
+	local sessions = vim.tbl_filter(function(id)
+		return id ~= ""
+	end, capytrace.list_sessions())
+
+	local function run(prompt_bufnr, action, ask)
+		local selection = action_state.get_selected_entry()
+		if not selection then
+			return
+		end
+		actions.close(prompt_bufnr)
+
+		local id = selection[1]
+		local reopen = function()
+			extension.manage(opts)
+		end
+
+		if ask then
+			vim.ui.input({ prompt = ask, default = id }, function(new_id)
+				if new_id and new_id ~= "" and new_id ~= id then
+					capytrace.manage_session(action, id, new_id, reopen)
+				end
+			end)
+			return
+		end
+
+		vim.ui.select({ "Yes", "No" }, { prompt = action .. " " .. id .. "?" }, function(choice)
+			if choice == "Yes" then
+				capytrace.manage_session(action, id, nil, reopen)
+			end
+		end)
+	end
+
+	pickers
+		.new(opts, {
+			prompt_title = "CapyTrace Manage Sessions (<C-r> rename, <C-y> copy, <C-d> delete, <C-a> archive)",
+			finder = finders.new_table({ results = sessions }),
+			sorter = conf.generic_sorter(opts),
+			attach_mappings = function(prompt_bufnr, map)
+				actions.select_default:replace(function()
+					local selection = action_state.get_selected_entry()
+					actions.close(prompt_bufnr)
+					local save_path = require("capytrace.config").get().save_path
+					local report = save_path .. "/" .. selection[1] .. ".md"
+					if vim.fn.filereadable(report) == 1 then
+						vim.cmd("edit " .. vim.fn.fnameescape(report))
+					else
+						vim.cmd("edit " .. vim.fn.fnameescape(save_path .. "/" .. selection[1] .. "_raw.json"))
+					end
+				end)
+				map({ "i", "n" }, "<C-r>", function()
+					run(prompt_bufnr, "rename", "New session id: ")
+				end)
+				map({ "i", "n" }, "<C-y>", function()
+					run(prompt_bufnr, "copy", "Copy to session id: ")
+				end)
+				map({ "i", "n" }, "<C-d>", function()
+					run(prompt_bufnr, "delete")
+				end)
+				map({ "i", "n" }, "<C-a>", function()
+					run(prompt_bufnr, "archive")
+				end)
+				return true
+			end,
+		})
+		:find()
+end
+
 return telescope.register_extension({
 	exports = {
 		sessions = extension.sessions,
 		notes = extension.notes,
+		manage = extension.manage,
 	},
 })